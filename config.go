@@ -5,8 +5,10 @@ import (
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"log"
+	"strings"
 )
 
 type Config interface {
@@ -19,6 +21,27 @@ type Config interface {
 	OnOk()
 }
 
+// findConfig 按名称查找已保存的配置
+func (w *Window) findConfig(name string) Config {
+	for _, cfg := range w.confs {
+		if cfg.Name() == name {
+			return cfg
+		}
+	}
+	return nil
+}
+
+// splitList 解析逗号分隔的列表，忽略空白项
+func splitList(s string) []string {
+	items := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 func (w *Window) showCreateConfigDialog() {
 	sshConf := &SSHConfig{}
 	dockerConf := &DockerConfig{}
@@ -44,9 +67,18 @@ func (w *Window) showCreateConfigDialog() {
 	})
 	title := "Create Config"
 	typeSelect.SetSelectedIndex(0)
-	box := container.NewVBox(typeSelect, sshForm, dockerForm, k8sForm)
+	var dlg *dialog.ConfirmDialog
+	importSSHButton := widget.NewButtonWithIcon("Import from ~/.ssh/config", theme.DownloadIcon(), func() {
+		dlg.Hide()
+		w.showImportSSHConfigDialog()
+	})
+	importKubeButton := widget.NewButtonWithIcon("Import from kubeconfig", theme.DownloadIcon(), func() {
+		dlg.Hide()
+		w.showImportKubeconfigDialog()
+	})
+	box := container.NewVBox(container.NewBorder(nil, nil, nil, container.NewHBox(importSSHButton, importKubeButton), typeSelect), sshForm, dockerForm, k8sForm)
 
-	dlg := dialog.NewCustomConfirm(title, "OK", "Cancel", box, func(b bool) {
+	dlg = dialog.NewCustomConfirm(title, "OK", "Cancel", box, func(b bool) {
 		if b {
 			switch typeSelect.Selected {
 			case "SSH":
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// containerFiles 在容器中上传和下载文件，Docker 和 K8S 会话分别实现
+type containerFiles interface {
+	// WorkDir 返回上传文件的默认目录，即会话的工作目录
+	WorkDir(ctx context.Context) (string, error)
+	// Upload 将 src 写入容器中的 dst 文件，size 必须是 src 的准确大小
+	Upload(ctx context.Context, dst string, src io.Reader, size int64, mode os.FileMode) error
+	// Download 读取容器中的 src 文件，返回文件内容和大小
+	Download(ctx context.Context, src string) (io.ReadCloser, int64, error)
+}
+
+// tarFile 将 src 作为名为 name 的单个文件写入 tar 流
+func tarFile(w io.Writer, name string, src io.Reader, size int64, mode os.FileMode) error {
+	tw := tar.NewWriter(w)
+	err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Size:     size,
+		Mode:     int64(mode.Perm()),
+		ModTime:  time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(tw, src, size); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// tarPipe 在后台将 src 打包为 tar 流，返回可以读取 tar 流的管道
+func tarPipe(name string, src io.Reader, size int64, mode os.FileMode) io.ReadCloser {
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(tarFile(writer, name, src, size, mode))
+	}()
+	return reader
+}
+
+// untarFile 读取 tar 流中的第一个文件，只支持普通文件
+func untarFile(r io.Reader) (io.Reader, *tar.Header, error) {
+	tr := tar.NewReader(r)
+	header, err := tr.Next()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, errors.New("file not found")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if header.Typeflag != tar.TypeReg {
+		return nil, nil, fmt.Errorf("%s is not a regular file", header.Name)
+	}
+	return tr, header, nil
+}
+
+// readCloser 读取 Reader，关闭时调用 close
+type readCloser struct {
+	io.Reader
+	close func() error
+}
+
+func (r *readCloser) Close() error {
+	return r.close()
+}
+
+// currentFiles 返回当前标签页的容器文件操作，不是容器会话时返回 nil
+func (w *Window) currentFiles() containerFiles {
+	item := w.tabs.Selected()
+	if item == nil {
+		return nil
+	}
+	if term, ok := w.terms[item]; ok {
+		return term.Files()
+	}
+	return nil
+}
+
+// uploadToContainer 上传本地文件到容器中的 dst 并显示进度，不能在主线程中调用
+func (w *Window) uploadToContainer(files containerFiles, src fyne.URIReadCloser, dst string) error {
+	var size int64 = -1
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(src.URI().Path()); err == nil {
+		size = info.Size()
+		mode = info.Mode()
+	}
+	var reader io.Reader = src
+	if size < 0 {
+		// tar 头需要文件大小，无法获取时先读入内存
+		data, err := io.ReadAll(src)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+		size = int64(len(data))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	pipeReader, pipeWriter := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := files.Upload(ctx, dst, pipeReader, size, mode)
+		pipeReader.CloseWithError(err)
+		done <- err
+	}()
+	err := w.transfer("Uploading "+src.URI().Name(), pipeWriter, reader, size)
+	pipeWriter.CloseWithError(err)
+	if err != nil {
+		cancel()
+		<-done
+		return err
+	}
+	return <-done
+}
+
+// downloadFromContainer 下载容器中的 src 文件到 dst 并显示进度，不能在主线程中调用
+func (w *Window) downloadFromContainer(files containerFiles, src string, dst io.Writer) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reader, size, err := files.Download(ctx, src)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+	return w.transfer("Downloading "+path.Base(src), dst, reader, size)
+}
+
+// showUploadDialog 选择本地文件上传到当前容器会话
+func (w *Window) showUploadDialog() {
+	files := w.currentFiles()
+	if files == nil {
+		w.showError(errors.New("upload is only available in Docker and K8S sessions"))
+		return
+	}
+	dlg := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			w.showError(err)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		go w.confirmUpload(files, []fyne.URIReadCloser{reader})
+	}, w.win)
+	dlg.Show()
+}
+
+// confirmUpload 显示上传目标并由用户确认，确认后依次上传，不能在主线程中调用
+// 终端中 cd 切换的目录无法获取，默认填入会话的工作目录，用户可以修改
+func (w *Window) confirmUpload(files containerFiles, readers []fyne.URIReadCloser) {
+	closeAll := func() {
+		for _, reader := range readers {
+			reader.Close()
+		}
+	}
+	dir, err := files.WorkDir(context.Background())
+	if err != nil {
+		closeAll()
+		w.showError(err)
+		return
+	}
+
+	fyne.Do(func() {
+		names := make([]string, len(readers))
+		for i, reader := range readers {
+			names[i] = reader.URI().Name()
+		}
+		dstEntry := widget.NewEntry()
+		var items []*widget.FormItem
+		if len(readers) == 1 {
+			dstEntry.SetText(path.Join(dir, names[0]))
+			dstEntry.Validator = validateContainerPath
+			items = []*widget.FormItem{widget.NewFormItem("Destination", dstEntry)}
+		} else {
+			dstEntry.SetText(dir)
+			dstEntry.Validator = func(s string) error {
+				if !path.IsAbs(strings.TrimSpace(s)) {
+					return errors.New("path must be absolute")
+				}
+				return nil
+			}
+			filesLabel := widget.NewLabel(strings.Join(names, ", "))
+			filesLabel.Wrapping = fyne.TextWrapWord
+			items = []*widget.FormItem{
+				widget.NewFormItem("Files", filesLabel),
+				widget.NewFormItem("Directory", dstEntry),
+			}
+		}
+		form := dialog.NewForm("Upload: "+strings.Join(names, ", "), "Upload", "Cancel", items, func(b bool) {
+			if !b {
+				closeAll()
+				return
+			}
+			dst := strings.TrimSpace(dstEntry.Text)
+			go func() {
+				defer closeAll()
+				for i, reader := range readers {
+					target := dst
+					if len(readers) > 1 {
+						target = path.Join(dst, names[i])
+					}
+					err := w.uploadToContainer(files, reader, target)
+					if errors.Is(err, errTransferCanceled) {
+						return
+					}
+					if err != nil {
+						w.showError(err)
+					}
+				}
+			}()
+		}, w.win)
+		form.Resize(fyne.NewSize(500, 0))
+		form.Show()
+	})
+}
+
+// showDownloadDialog 输入容器中的文件路径，下载保存到本地
+func (w *Window) showDownloadDialog() {
+	files := w.currentFiles()
+	if files == nil {
+		w.showError(errors.New("download is only available in Docker and K8S sessions"))
+		return
+	}
+	srcEntry := widget.NewEntry()
+	srcEntry.SetPlaceHolder("/path/to/file")
+	srcEntry.Validator = validateContainerPath
+	go func() {
+		if dir, err := files.WorkDir(context.Background()); err == nil {
+			fyne.Do(func() {
+				if srcEntry.Text == "" {
+					srcEntry.SetText(strings.TrimSuffix(dir, "/") + "/")
+				}
+			})
+		}
+	}()
+	form := dialog.NewForm("Download", "Next", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("File", srcEntry),
+	}, func(b bool) {
+		if !b {
+			return
+		}
+		src := strings.TrimSpace(srcEntry.Text)
+		save := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+			if err != nil {
+				w.showError(err)
+				return
+			}
+			if writer == nil {
+				return
+			}
+			go func() {
+				defer writer.Close()
+				if err := w.downloadFromContainer(files, src, writer); err != nil && !errors.Is(err, errTransferCanceled) {
+					w.showError(err)
+				}
+			}()
+		}, w.win)
+		save.SetFileName(path.Base(src))
+		save.Show()
+	}, w.win)
+	form.Resize(fyne.NewSize(500, 0))
+	form.Show()
+}
+
+// validateContainerPath 校验容器中的文件路径，必须是绝对路径且不能是目录
+func validateContainerPath(s string) error {
+	s = strings.TrimSpace(s)
+	if !path.IsAbs(s) {
+		return errors.New("path must be absolute")
+	}
+	if strings.HasSuffix(s, "/") {
+		return errors.New("path must be a file")
+	}
+	return nil
+}
+
+// uploadDropped 将拖放到窗口的本地文件上传到当前容器会话，确认上传目标后开始上传
+func (w *Window) uploadDropped(uris []fyne.URI) {
+	files := w.currentFiles()
+	if files == nil || len(uris) == 0 {
+		return
+	}
+	go func() {
+		readers := make([]fyne.URIReadCloser, 0, len(uris))
+		for _, uri := range uris {
+			if info, err := os.Stat(uri.Path()); err != nil || info.IsDir() {
+				w.showError(fmt.Errorf("%s is not a file", uri.Path()))
+				continue
+			}
+			file, err := os.Open(uri.Path())
+			if err != nil {
+				w.showError(err)
+				continue
+			}
+			readers = append(readers, &fileURIReadCloser{File: file, uri: uri})
+		}
+		if len(readers) > 0 {
+			w.confirmUpload(files, readers)
+		}
+	}()
+}
+
+// fileURIReadCloser 将本地文件包装为 fyne.URIReadCloser
+type fileURIReadCloser struct {
+	*os.File
+	uri fyne.URI
+}
+
+func (f *fileURIReadCloser) URI() fyne.URI {
+	return f.uri
+}
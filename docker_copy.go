@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// dockerFiles 通过 Docker 的归档接口在容器中上传和下载文件，与 docker cp 相同
+type dockerFiles struct {
+	cli         client.APIClient
+	containerID string
+	workDir     string // 配置的工作目录，为空时使用容器的工作目录
+}
+
+func (f *dockerFiles) WorkDir(ctx context.Context) (string, error) {
+	if f.workDir != "" {
+		return f.workDir, nil
+	}
+	inspect, err := f.cli.ContainerInspect(ctx, f.containerID)
+	if err != nil {
+		return "", err
+	}
+	if inspect.Config != nil && inspect.Config.WorkingDir != "" {
+		return inspect.Config.WorkingDir, nil
+	}
+	return "/", nil
+}
+
+func (f *dockerFiles) Upload(ctx context.Context, dst string, src io.Reader, size int64, mode os.FileMode) error {
+	content := tarPipe(path.Base(dst), src, size, mode)
+	defer content.Close()
+	return f.cli.CopyToContainer(ctx, f.containerID, path.Dir(dst), content, containerTypes.CopyToContainerOptions{})
+}
+
+func (f *dockerFiles) Download(ctx context.Context, src string) (io.ReadCloser, int64, error) {
+	content, stat, err := f.cli.CopyFromContainer(ctx, f.containerID, src)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !stat.Mode.IsRegular() {
+		content.Close()
+		return nil, 0, fmt.Errorf("%s is not a regular file", src)
+	}
+	reader, header, err := untarFile(content)
+	if err != nil {
+		content.Close()
+		return nil, 0, err
+	}
+	return &readCloser{Reader: reader, close: content.Close}, header.Size, nil
+}
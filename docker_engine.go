@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// localDockerSocket 本地容器引擎提供的 Docker 兼容 API socket
+type localDockerSocket struct {
+	Engine string
+	Path   string
+}
+
+func (s localDockerSocket) Host() string {
+	return "unix://" + s.Path
+}
+
+func (s localDockerSocket) String() string {
+	return fmt.Sprintf("%s (%s)", s.Engine, s.Host())
+}
+
+// dockerSocketCandidates 返回常见容器引擎的 socket 位置，按优先级排序
+// runtimeDir 为 XDG_RUNTIME_DIR，为空时使用 /run/user/<uid>
+func dockerSocketCandidates(home, runtimeDir string, uid int) []localDockerSocket {
+	if runtimeDir == "" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", uid)
+	}
+	return []localDockerSocket{
+		{Engine: "Docker", Path: defaultDockerSocket},
+		{Engine: "Docker Desktop", Path: path.Join(home, ".docker/run/docker.sock")},
+		{Engine: "Docker Desktop", Path: path.Join(home, ".docker/desktop/docker.sock")},
+		{Engine: "Docker (rootless)", Path: path.Join(runtimeDir, "docker.sock")},
+		{Engine: "Podman", Path: path.Join(runtimeDir, "podman/podman.sock")},
+		{Engine: "Podman (root)", Path: "/run/podman/podman.sock"},
+		{Engine: "Podman Machine", Path: path.Join(home, ".local/share/containers/podman/machine/podman.sock")},
+		{Engine: "Colima", Path: path.Join(home, ".colima/default/docker.sock")},
+		{Engine: "Colima", Path: path.Join(home, ".config/colima/default/docker.sock")},
+		{Engine: "Rancher Desktop", Path: path.Join(home, ".rd/docker.sock")},
+	}
+}
+
+// discoverDockerSockets 返回存在的 socket，exists 判断路径上是否有可连接的 socket
+func discoverDockerSockets(candidates []localDockerSocket, exists func(path string) bool) []localDockerSocket {
+	found := make([]localDockerSocket, 0)
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		if seen[candidate.Path] || !exists(candidate.Path) {
+			continue
+		}
+		seen[candidate.Path] = true
+		found = append(found, candidate)
+	}
+	return found
+}
+
+func isSocket(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
+
+// localDockerSockets 查找本机上的 Docker、Podman、Colima 和 Rancher Desktop socket
+func localDockerSockets() []localDockerSocket {
+	home, _ := os.UserHomeDir()
+	return discoverDockerSockets(dockerSocketCandidates(home, os.Getenv("XDG_RUNTIME_DIR"), os.Getuid()), isSocket)
+}
+
+// expandDockerHost 展开 unix:// 地址中的环境变量，$UID 通常不会导出到环境变量，未设置时使用当前用户的 uid
+// 例如 unix:///run/user/$UID/podman/podman.sock，其他地址指向远程主机，不使用本机的变量
+func expandDockerHost(host string, uid int) (string, error) {
+	if !strings.HasPrefix(host, "unix://") {
+		return host, nil
+	}
+	var missing []string
+	expanded := os.Expand(host, func(key string) string {
+		if value, ok := os.LookupEnv(key); ok {
+			return value
+		}
+		if key == "UID" {
+			return strconv.Itoa(uid)
+		}
+		missing = append(missing, "$"+key)
+		return ""
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("unresolved variable %s in docker host %s", strings.Join(missing, ", "), host)
+	}
+	return expanded, nil
+}
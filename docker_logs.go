@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+	"strconv"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// containerLogsOptions 将界面参数转换为 LogsOptions，起始时间统一转换为 Unix 时间戳
+func containerLogsOptions(opt *LogOpt, now time.Time) (containerTypes.LogsOptions, error) {
+	opts := containerTypes.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opt.Follow,
+		Timestamps: opt.Timestamps,
+		Tail:       "all",
+	}
+	lines, ok, err := opt.tailLines()
+	if err != nil {
+		return opts, err
+	}
+	if ok {
+		opts.Tail = strconv.FormatInt(lines, 10)
+	}
+	d, t, err := opt.since(now)
+	if err != nil {
+		return opts, err
+	}
+	if d > 0 {
+		t = now.Add(-d)
+	}
+	if !t.IsZero() {
+		opts.Since = strconv.FormatInt(t.Unix(), 10)
+	}
+	return opts, nil
+}
+
+// containerOutput 返回适合终端显示的容器输出
+// 非 TTY 容器的输出带有 stdcopy 头，需要拆分后合并 stdout 和 stderr，并转换换行
+func containerOutput(stream io.Reader, tty bool) io.Reader {
+	if tty {
+		return eofReader{r: stream}
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := stdcopy.StdCopy(pw, pw, stream)
+		pw.CloseWithError(err)
+	}()
+	return &crlfReader{r: pr}
+}
+
+// showLogsDialog 选择日志参数后打开日志标签页
+func (c *DockerConfig) showLogsDialog(win *Window, dockerCli client.APIClient, cont containerSummary) {
+	items, optFromForm := logOptForm(false)
+	dlg := dialog.NewForm("Logs: "+cont.Name, "Open", "Cancel", items, func(b bool) {
+		if b {
+			c.logs(win, dockerCli, cont, optFromForm())
+		}
+	}, win.win)
+	dlg.Resize(fyne.NewSize(400, 0))
+	dlg.Show()
+}
+
+// logs 打开只读的日志标签页，关闭标签页时停止读取
+func (c *DockerConfig) logs(win *Window, dockerCli client.APIClient, cont containerSummary, opt *LogOpt) {
+	opts, err := containerLogsOptions(opt, time.Now())
+	if err != nil {
+		win.showError(err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	term := NewTerm("logs: "+cont.Name, c)
+	term.AddCloseListener(cancel)
+
+	go func() {
+		inspect, err := dockerCli.ContainerInspect(ctx, cont.ID)
+		if err != nil {
+			cancel()
+			win.showError(err)
+			return
+		}
+		stream, err := dockerCli.ContainerLogs(ctx, cont.ID, opts)
+		if err != nil {
+			cancel()
+			win.showError(err)
+			return
+		}
+		defer stream.Close()
+		tty := inspect.Config != nil && inspect.Config.Tty
+		fyne.Do(func() {
+			win.AddTermTab(term)
+		})
+		err = term.RunWithReaderAndWriter(readOnlyInput{}, containerOutput(stream, tty))
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/client"
+)
+
+// docker compose 在容器上设置的标签
+const (
+	composeProjectLabel = "com.docker.compose.project"
+	composeServiceLabel = "com.docker.compose.service"
+)
+
+// containerSummary 选择容器时显示的摘要信息
+type containerSummary struct {
+	ID      string
+	Name    string
+	Image   string
+	State   string // running、exited 等
+	Status  string // 例如 Up 3 hours
+	Ports   string
+	Project string // compose 项目，非 compose 容器为空
+	Service string
+}
+
+func (s containerSummary) Running() bool {
+	return s.State == containerTypes.StateRunning
+}
+
+// summarizeContainer 提取容器的摘要信息
+func summarizeContainer(cont containerTypes.Summary) containerSummary {
+	name := cont.ID
+	if len(name) > 12 {
+		name = name[:12]
+	}
+	if len(cont.Names) > 0 {
+		name = strings.TrimPrefix(cont.Names[0], "/")
+	}
+	return containerSummary{
+		ID:      cont.ID,
+		Name:    name,
+		Image:   cont.Image,
+		State:   cont.State,
+		Status:  cont.Status,
+		Ports:   formatContainerPorts(cont.Ports),
+		Project: cont.Labels[composeProjectLabel],
+		Service: cont.Labels[composeServiceLabel],
+	}
+}
+
+// formatContainerPorts 以 docker ps 的风格格式化端口，例如 0.0.0.0:8080->80/tcp
+func formatContainerPorts(ports []containerTypes.Port) string {
+	items := make([]string, 0, len(ports))
+	seen := make(map[string]bool)
+	for _, p := range ports {
+		item := fmt.Sprintf("%d/%s", p.PrivatePort, p.Type)
+		if p.PublicPort != 0 {
+			item = fmt.Sprintf("%s:%d->%s", p.IP, p.PublicPort, item)
+		}
+		// IPv4 和 IPv6 会各返回一条相同的映射
+		if p.IP == "::" {
+			item = fmt.Sprintf("[::]:%d->%d/%s", p.PublicPort, p.PrivatePort, p.Type)
+		}
+		if !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	sort.Strings(items)
+	return strings.Join(items, ", ")
+}
+
+// listContainers 列出容器，按 compose 项目和名称排序，非 compose 容器排在最后
+func listContainers(ctx context.Context, dockerCli client.APIClient, all bool) ([]containerSummary, error) {
+	list, err := dockerCli.ContainerList(ctx, containerTypes.ListOptions{All: all})
+	if err != nil {
+		return nil, err
+	}
+	conts := make([]containerSummary, 0, len(list))
+	for _, cont := range list {
+		conts = append(conts, summarizeContainer(cont))
+	}
+	sort.Slice(conts, func(i, j int) bool {
+		if conts[i].Project != conts[j].Project {
+			if conts[i].Project == "" || conts[j].Project == "" {
+				return conts[j].Project == ""
+			}
+			return conts[i].Project < conts[j].Project
+		}
+		return conts[i].Name < conts[j].Name
+	})
+	return conts, nil
+}
+
+// filterContainers 按名称、镜像或 compose 项目的子串过滤容器，忽略大小写
+func filterContainers(conts []containerSummary, text string) []containerSummary {
+	text = strings.ToLower(strings.TrimSpace(text))
+	if text == "" {
+		return conts
+	}
+	filtered := make([]containerSummary, 0)
+	for _, cont := range conts {
+		for _, field := range []string{cont.Name, cont.Image, cont.Project} {
+			if strings.Contains(strings.ToLower(field), text) {
+				filtered = append(filtered, cont)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// containerRow 容器列表中的一行，Container 为空时表示 compose 项目的分组标题
+type containerRow struct {
+	Project   string
+	Container *containerSummary
+}
+
+// groupContainers 在每个 compose 项目前插入分组标题，conts 需要已按项目排序
+func groupContainers(conts []containerSummary) []containerRow {
+	rows := make([]containerRow, 0, len(conts))
+	project := ""
+	for i := range conts {
+		cont := &conts[i]
+		if cont.Project != "" && (i == 0 || cont.Project != project) {
+			rows = append(rows, containerRow{Project: cont.Project})
+		} else if cont.Project == "" && project != "" {
+			rows = append(rows, containerRow{Project: ""})
+		}
+		project = cont.Project
+		rows = append(rows, containerRow{Project: cont.Project, Container: cont})
+	}
+	return rows
+}
+
+// containerAction 选择容器后可执行的操作
+type containerAction struct {
+	Name   string
+	Icon   fyne.Resource
+	Global bool // 不需要选择容器，例如运行新容器
+	Run    func(cont containerSummary)
+}
+
+// showContainerPicker 显示可搜索的容器列表，按 compose 项目分组，选择容器后执行对应的操作
+func (w *Window) showContainerPicker(title string, dockerCli client.APIClient, actions ...containerAction) {
+	var conts []containerSummary
+	var rows []containerRow
+	var selected *containerSummary
+
+	filterEntry := widget.NewEntry()
+	filterEntry.SetPlaceHolder("Filter by name, image or project")
+	stoppedCheck := widget.NewCheck("Show stopped", nil)
+	status := widget.NewLabel("")
+
+	list := widget.NewList(func() int {
+		return len(rows)
+	}, func() fyne.CanvasObject {
+		name := widget.NewLabel("")
+		name.Truncation = fyne.TextTruncateEllipsis
+		info := widget.NewLabel("")
+		info.Truncation = fyne.TextTruncateEllipsis
+		return container.NewBorder(nil, nil, widget.NewIcon(nil), nil, container.NewGridWithColumns(2, name, info))
+	}, func(id widget.ListItemID, object fyne.CanvasObject) {
+		box := object.(*fyne.Container)
+		grid := box.Objects[0].(*fyne.Container)
+		icon := box.Objects[1].(*widget.Icon)
+		name := grid.Objects[0].(*widget.Label)
+		info := grid.Objects[1].(*widget.Label)
+
+		row := rows[id]
+		if row.Container == nil {
+			icon.SetResource(theme.FolderIcon())
+			name.TextStyle = fyne.TextStyle{Bold: true}
+			if row.Project == "" {
+				name.SetText("(no project)")
+			} else {
+				name.SetText(row.Project)
+			}
+			info.SetText("")
+			return
+		}
+		cont := row.Container
+		if cont.Running() {
+			icon.SetResource(theme.MediaPlayIcon())
+		} else {
+			icon.SetResource(theme.MediaStopIcon())
+		}
+		name.TextStyle = fyne.TextStyle{}
+		name.SetText(cont.Name)
+		details := []string{cont.Image, cont.Status}
+		if cont.Ports != "" {
+			details = append(details, cont.Ports)
+		}
+		info.SetText(strings.Join(details, "  "))
+	})
+	list.OnSelected = func(id widget.ListItemID) {
+		if rows[id].Container == nil {
+			// 分组标题不能选择
+			selected = nil
+			list.Unselect(id)
+			return
+		}
+		cont := *rows[id].Container
+		selected = &cont
+	}
+	list.OnUnselected = func(id widget.ListItemID) {
+		selected = nil
+	}
+
+	applyFilter := func() {
+		visible := filterContainers(conts, filterEntry.Text)
+		rows = groupContainers(visible)
+		selected = nil
+		list.UnselectAll()
+		list.Refresh()
+		status.SetText(fmt.Sprintf("%d of %d containers", len(visible), len(conts)))
+	}
+	filterEntry.OnChanged = func(string) {
+		applyFilter()
+	}
+	reload := func() {
+		all := stoppedCheck.Checked
+		status.SetText("Loading...")
+		go func() {
+			result, err := listContainers(context.Background(), dockerCli, all)
+			fyne.Do(func() {
+				if err != nil {
+					status.SetText("")
+					w.showError(err)
+					return
+				}
+				conts = result
+				applyFilter()
+			})
+		}()
+	}
+	stoppedCheck.OnChanged = func(bool) {
+		reload()
+	}
+	reload()
+
+	top := container.NewBorder(nil, nil, nil, container.NewHBox(stoppedCheck, widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), reload)), filterEntry)
+	content := container.NewBorder(top, status, nil, nil, list)
+
+	dlg := dialog.NewCustomWithoutButtons(title, content, w.win)
+	buttons := []fyne.CanvasObject{widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), dlg.Hide)}
+	for _, action := range actions {
+		button := widget.NewButtonWithIcon(action.Name, action.Icon, func() {
+			if action.Global {
+				dlg.Hide()
+				action.Run(containerSummary{})
+				return
+			}
+			if selected == nil {
+				return
+			}
+			dlg.Hide()
+			action.Run(*selected)
+		})
+		if !action.Global {
+			button.Importance = widget.HighImportance
+		}
+		buttons = append(buttons, button)
+	}
+	dlg.SetButtons(buttons)
+	dlg.Resize(fyne.NewSize(800, 500))
+	dlg.Show()
+}
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/docker/docker/client"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// 远程主机上 Docker 守护进程的默认 socket
+const defaultDockerSocket = "/var/run/docker.sock"
+
+// 通过 SSH 连接时使用的占位地址，实际连接由 sshDockerDialer 建立
+const sshDockerHost = "http://docker.example.com"
+
+// sshDockerTarget 解析后的 ssh:// 地址
+type sshDockerTarget struct {
+	User   string
+	Host   string
+	Port   int // 未指定时为 0
+	Socket string
+}
+
+// parseSSHDockerHost 解析 ssh://[user@]host[:port][/path/to/docker.sock]
+func parseSSHDockerHost(host string) (*sshDockerTarget, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %s: %w", host, err)
+	}
+	if u.Scheme != "ssh" {
+		return nil, fmt.Errorf("invalid docker host %s: not an ssh:// address", host)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("invalid docker host %s: missing host", host)
+	}
+	if _, ok := u.User.Password(); ok {
+		return nil, fmt.Errorf("invalid docker host %s: password is not allowed, use an SSH config instead", host)
+	}
+	target := &sshDockerTarget{User: u.User.Username(), Host: u.Hostname(), Socket: u.Path}
+	if p := u.Port(); p != "" {
+		if target.Port, err = strconv.Atoi(p); err != nil || target.Port <= 0 || target.Port > 65535 {
+			return nil, fmt.Errorf("invalid docker host %s: invalid port %s", host, p)
+		}
+	}
+	if target.Socket == "" || target.Socket == "/" {
+		target.Socket = defaultDockerSocket
+	}
+	return target, nil
+}
+
+// findSSHConfigForHost 查找连接远程 Docker 使用的 SSH 配置
+// 主机名与配置名称相同时直接使用该配置，否则按主机、用户和端口匹配
+func (w *Window) findSSHConfigForHost(target *sshDockerTarget) (*SSHConfig, error) {
+	if cfg, ok := w.findConfig(target.Host).(*SSHConfig); ok {
+		return cfg, nil
+	}
+	for _, cfg := range w.confs {
+		sshCfg, ok := cfg.(*SSHConfig)
+		if !ok || sshCfg.data.Host != target.Host {
+			continue
+		}
+		if target.User != "" && sshCfg.data.User != target.User {
+			continue
+		}
+		if target.Port != 0 && sshCfg.data.Port != target.Port {
+			continue
+		}
+		return sshCfg, nil
+	}
+	return nil, fmt.Errorf("no SSH config found for %s, create an SSH config for this host first", target.Host)
+}
+
+// sshDockerDialer 通过 SSH 连接远程主机上的 Docker socket
+// 所有 HTTP 连接共用同一个 SSH 连接，连接断开后在下一次拨号时重连
+type sshDockerDialer struct {
+	socket  string
+	connect func() (*ssh.Client, error)
+
+	lock   sync.Mutex
+	client *ssh.Client
+}
+
+// newSSHDockerDialer 使用 SSH 配置的认证、跳板机和主机密钥校验连接远程主机
+func newSSHDockerDialer(win *Window, cfg *SSHConfig, socket string) *sshDockerDialer {
+	return &sshDockerDialer{
+		socket: socket,
+		connect: func() (*ssh.Client, error) {
+			hops, err := cfg.jumpChain(win)
+			if err != nil {
+				return nil, err
+			}
+			var ag agent.ExtendedAgent
+			if hopsUseAgent(hops) {
+				var closer io.Closer
+				ag, closer, err = openAgent()
+				if err != nil {
+					// agent 不可用时继续使用其他认证方式
+					log.Printf("ssh-agent unavailable: %v", err)
+				} else {
+					// 只用于认证，连接建立后即可关闭
+					defer closer.Close()
+				}
+			}
+			client, _, err := dialChain(win, hops, ag)
+			return client, err
+		},
+	}
+}
+
+// sshClient 返回当前的 SSH 连接，未连接时建立新连接
+func (d *sshDockerDialer) sshClient() (*ssh.Client, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.client == nil {
+		client, err := d.connect()
+		if err != nil {
+			return nil, err
+		}
+		d.client = client
+		go func() {
+			client.Wait()
+			d.lock.Lock()
+			if d.client == client {
+				d.client = nil
+			}
+			d.lock.Unlock()
+		}()
+	}
+	return d.client, nil
+}
+
+// DialContext 忽略 Docker 客户端传入的地址，总是连接远程 socket
+func (d *sshDockerDialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	client, err := d.sshClient()
+	if err != nil {
+		return nil, err
+	}
+	conn, err := client.DialContext(ctx, "unix", d.socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to dial %s via %s: %w", d.socket, client.RemoteAddr(), err)
+	}
+	return conn, nil
+}
+
+func (d *sshDockerDialer) Close() error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.client == nil {
+		return nil
+	}
+	err := d.client.Close()
+	d.client = nil
+	return err
+}
+
+// tlsConfig 构建连接 tcp:// 守护进程的 TLS 配置，未配置证书时返回 nil
+func (d *DockerConfigData) tlsConfig() (*tls.Config, error) {
+	key, err := d.getClientKey()
+	if err != nil {
+		return nil, err
+	}
+	if d.CAData == "" && d.ClientCert == "" && key == "" {
+		return nil, nil
+	}
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	if d.CAData != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(d.CAData)) {
+			return nil, errors.New("no valid PEM certificate found")
+		}
+		config.RootCAs = pool
+	}
+	if d.ClientCert == "" && key == "" {
+		return config, nil
+	}
+	if d.ClientCert == "" || key == "" {
+		return nil, errors.New("client certificate and client key must be provided together")
+	}
+	cert, err := tls.X509KeyPair([]byte(d.ClientCert), []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate or key: %w", err)
+	}
+	config.Certificates = []tls.Certificate{cert}
+	return config, nil
+}
+
+// withTLSConfig 使用内存中的证书设置 TLS，与 client.WithTLSClientConfig 相同但不需要证书文件
+func withTLSConfig(config *tls.Config) client.Opt {
+	return func(c *client.Client) error {
+		transport, ok := c.HTTPClient().Transport.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("cannot apply tls config to transport: %T", c.HTTPClient().Transport)
+		}
+		transport.TLSClientConfig = config
+		return nil
+	}
+}
+
+// clientOpts 返回创建 Docker 客户端的参数，ssh:// 地址通过 SSH 配置连接
+func (c *DockerConfig) clientOpts(win *Window) ([]client.Opt, error) {
+	host := strings.TrimSpace(c.data.Host)
+	// Podman 等兼容引擎支持的 API 版本通常低于客户端的默认版本
+	opts := []client.Opt{client.WithAPIVersionNegotiation()}
+	if strings.HasPrefix(host, "ssh://") {
+		if c.data.CAData != "" || c.data.ClientCert != "" {
+			return nil, errors.New("TLS certificates can not be used with ssh:// hosts")
+		}
+		target, err := parseSSHDockerHost(host)
+		if err != nil {
+			return nil, err
+		}
+		sshCfg, err := win.findSSHConfigForHost(target)
+		if err != nil {
+			return nil, err
+		}
+		dialer := c.sshDialerFor(host, func() *sshDockerDialer {
+			return newSSHDockerDialer(win, sshCfg, target.Socket)
+		})
+		return append(opts, client.WithHost(sshDockerHost), client.WithDialContext(dialer.DialContext)), nil
+	}
+
+	host, err := expandDockerHost(host, os.Getuid())
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := c.data.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		// 未指定地址时会连接本机 socket，证书不会生效，必须明确指定 tcp:// 地址
+		if !strings.HasPrefix(host, "tcp://") && !strings.HasPrefix(host, "https://") {
+			return nil, fmt.Errorf("TLS certificates can only be used with tcp:// hosts")
+		}
+	} else if host == "" {
+		// 未指定地址时使用本机找到的第一个 socket
+		if sockets := localDockerSockets(); len(sockets) > 0 {
+			host = sockets[0].Host()
+		}
+	}
+	if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+	if tlsConfig != nil {
+		opts = append(opts, withTLSConfig(tlsConfig))
+	}
+	return opts, nil
+}
+
+// sshDialerFor 返回复用的 SSH 拨号器，地址变化时关闭旧的连接
+func (c *DockerConfig) sshDialerFor(host string, create func() *sshDockerDialer) *sshDockerDialer {
+	c.dialerLock.Lock()
+	defer c.dialerLock.Unlock()
+	if c.dialer != nil && c.dialerHost == host {
+		return c.dialer
+	}
+	if c.dialer != nil {
+		c.dialer.Close()
+	}
+	c.dialer = create()
+	c.dialerHost = host
+	return c.dialer
+}
+
+// Close 关闭连接远程 Docker 使用的 SSH 连接
+func (c *DockerConfig) Close() error {
+	c.dialerLock.Lock()
+	defer c.dialerLock.Unlock()
+	if c.dialer == nil {
+		return nil
+	}
+	err := c.dialer.Close()
+	c.dialer = nil
+	return err
+}
+
+// dockerClient 创建 Docker 客户端
+func (c *DockerConfig) dockerClient(win *Window) (client.APIClient, error) {
+	opts, err := c.clientOpts(win)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewClientWithOpts(opts...)
+}
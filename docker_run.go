@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	containerTypes "github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/image"
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/jsonmessage"
+)
+
+// RunOpt 运行临时容器的参数，容器退出或标签页关闭后自动删除
+type RunOpt struct {
+	Image      string
+	Entrypoint string   // 为空时使用镜像的 ENTRYPOINT
+	Command    string   // 为空时使用镜像的 CMD
+	Mounts     []string // host:container[:ro]
+	Env        []string // KEY=VALUE
+	Network    string
+}
+
+// parseMounts 解析逗号或换行分隔的挂载列表，格式与 docker run -v 相同
+func parseMounts(s string) ([]string, error) {
+	mounts := make([]string, 0)
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\n", ","), ",") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		parts := strings.Split(line, ":")
+		if len(parts) < 2 || len(parts) > 3 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid mount: %s, expected host:container[:ro]", line)
+		}
+		if !path.IsAbs(parts[1]) {
+			return nil, fmt.Errorf("invalid mount: %s, container path must be absolute", line)
+		}
+		if len(parts) == 3 && parts[2] != "ro" && parts[2] != "rw" {
+			return nil, fmt.Errorf("invalid mount mode: %s, expected ro or rw", parts[2])
+		}
+		parts[0] = expandHome(parts[0])
+		mounts = append(mounts, strings.Join(parts, ":"))
+	}
+	return mounts, nil
+}
+
+// runConfig 返回创建容器的配置，与 docker run --rm -it 相同
+func runConfig(opt *RunOpt) (*containerTypes.Config, *containerTypes.HostConfig) {
+	config := &containerTypes.Config{
+		Image:        opt.Image,
+		Cmd:          strings.Fields(opt.Command),
+		Env:          opt.Env,
+		Tty:          true,
+		OpenStdin:    true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	}
+	if entrypoint := strings.Fields(opt.Entrypoint); len(entrypoint) > 0 {
+		config.Entrypoint = entrypoint
+	}
+	hostConfig := &containerTypes.HostConfig{
+		AutoRemove:  true,
+		Binds:       opt.Mounts,
+		NetworkMode: containerTypes.NetworkMode(opt.Network),
+	}
+	return config, hostConfig
+}
+
+// listImageTags 列出本地镜像的标签，忽略没有标签的镜像
+func listImageTags(ctx context.Context, dockerCli client.APIClient) ([]string, error) {
+	images, err := dockerCli.ImageList(ctx, image.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]string, 0, len(images))
+	for _, img := range images {
+		for _, tag := range img.RepoTags {
+			if tag != "<none>:<none>" {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags, nil
+}
+
+// pullProgress 拉取镜像的进度，按层汇总已下载的字节数
+type pullProgress struct {
+	Status  string
+	Current int64
+	Total   int64
+}
+
+// Fraction 返回 0 到 1 之间的进度，总大小未知时返回 0
+func (p pullProgress) Fraction() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Current) / float64(p.Total)
+}
+
+// pullImage 拉取镜像，每收到一条进度消息调用一次 progress
+func pullImage(ctx context.Context, dockerCli client.APIClient, ref string, progress func(pullProgress)) error {
+	stream, err := dockerCli.ImagePull(ctx, ref, image.PullOptions{})
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	type layer struct{ current, total int64 }
+	layers := make(map[string]*layer)
+	decoder := json.NewDecoder(stream)
+	for {
+		var msg jsonmessage.JSONMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if msg.Error != nil {
+			return fmt.Errorf("failed to pull %s: %s", ref, msg.Error.Message)
+		}
+		if msg.ID != "" && msg.Progress != nil && msg.Progress.Total > 0 {
+			// 只统计下载阶段，解压阶段的进度会重复计算大小
+			if msg.Status == "Downloading" {
+				layers[msg.ID] = &layer{current: msg.Progress.Current, total: msg.Progress.Total}
+			}
+		} else if msg.Status == "Download complete" || msg.Status == "Already exists" {
+			if l, ok := layers[msg.ID]; ok {
+				l.current = l.total
+			}
+		}
+		p := pullProgress{Status: msg.Status}
+		if msg.ID != "" {
+			p.Status = msg.ID + ": " + msg.Status
+		}
+		for _, l := range layers {
+			p.Current += l.current
+			p.Total += l.total
+		}
+		progress(p)
+	}
+}
+
+// ensureImage 本地没有镜像时拉取，拉取过程中显示进度，可以取消
+func (w *Window) ensureImage(dockerCli client.APIClient, ref string) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if _, err := dockerCli.ImageInspect(ctx, ref); err == nil {
+		return nil
+	} else if !client.IsErrNotFound(err) {
+		return err
+	}
+
+	var dlg *dialog.CustomDialog
+	status := widget.NewLabel("Pulling " + ref)
+	status.Truncation = fyne.TextTruncateEllipsis
+	bar := widget.NewProgressBar()
+	fyne.DoAndWait(func() {
+		dlg = dialog.NewCustomWithoutButtons("Pull Image", container.NewVBox(status, bar), w.win)
+		dlg.SetButtons([]fyne.CanvasObject{widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), cancel)})
+		dlg.Resize(fyne.NewSize(400, 0))
+		dlg.Show()
+	})
+	defer fyne.Do(func() {
+		dlg.Hide()
+	})
+	return pullImage(ctx, dockerCli, ref, func(p pullProgress) {
+		fyne.Do(func() {
+			status.SetText(p.Status)
+			bar.SetValue(p.Fraction())
+		})
+	})
+}
+
+// runContainer 创建并启动临时容器，在终端标签页中连接，关闭标签页时删除容器
+func (c *DockerConfig) runContainer(win *Window, dockerCli client.APIClient, opt *RunOpt) {
+	go func() {
+		if err := win.ensureImage(dockerCli, opt.Image); err != nil {
+			win.showError(err)
+			return
+		}
+		ctx := context.Background()
+		config, hostConfig := runConfig(opt)
+		created, err := dockerCli.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+		if err != nil {
+			win.showError(err)
+			return
+		}
+		for _, warning := range created.Warnings {
+			log.Println(warning)
+		}
+		remove := func() {
+			err := dockerCli.ContainerRemove(context.Background(), created.ID, containerTypes.RemoveOptions{Force: true})
+			if err != nil && !client.IsErrNotFound(err) {
+				log.Printf("Failed to remove container %s: %v", created.ID, err)
+			}
+		}
+
+		name := opt.Image
+		if len(created.ID) > 12 {
+			name += " (" + created.ID[:12] + ")"
+		}
+		term := NewTerm(name, c)
+		term.AddCloseListener(func() {
+			go remove()
+		})
+		err = c.attachTerm(win, dockerCli, term, created.ID, true, true, func() error {
+			return dockerCli.ContainerStart(ctx, created.ID, containerTypes.StartOptions{})
+		})
+		if err != nil {
+			remove()
+			win.showError(err)
+		}
+	}()
+}
+
+// showRunDialog 选择或输入镜像，设置参数后运行临时容器
+func (c *DockerConfig) showRunDialog(win *Window, dockerCli client.APIClient) {
+	imageEntry := widget.NewSelectEntry([]string{})
+	imageEntry.SetPlaceHolder("busybox:latest")
+	imageEntry.Validator = func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return errors.New("image is required")
+		}
+		return nil
+	}
+	entrypointEntry := widget.NewEntry()
+	entrypointEntry.SetPlaceHolder("(image default)")
+	commandEntry := widget.NewEntry()
+	commandEntry.SetPlaceHolder("(image default)")
+	mountsEntry := widget.NewEntry()
+	mountsEntry.MultiLine = true
+	mountsEntry.SetPlaceHolder("/host/path:/container/path:ro")
+	mountsEntry.Validator = func(s string) error {
+		_, err := parseMounts(s)
+		return err
+	}
+	envEntry := widget.NewEntry()
+	envEntry.MultiLine = true
+	envEntry.SetPlaceHolder("KEY=VALUE")
+	envEntry.Validator = func(s string) error {
+		_, err := parseEnvList(s)
+		return err
+	}
+	networkEntry := widget.NewSelectEntry([]string{"bridge", "host", "none"})
+	networkEntry.SetPlaceHolder("bridge")
+
+	go func() {
+		tags, err := listImageTags(context.Background(), dockerCli)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		fyne.Do(func() {
+			imageEntry.SetOptions(tags)
+		})
+	}()
+
+	dlg := dialog.NewForm("Run Container", "Run", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Image", imageEntry),
+		widget.NewFormItem("Entrypoint", entrypointEntry),
+		widget.NewFormItem("Command", commandEntry),
+		widget.NewFormItem("Mounts", mountsEntry),
+		widget.NewFormItem("Env", envEntry),
+		widget.NewFormItem("Network", networkEntry),
+	}, func(b bool) {
+		if !b {
+			return
+		}
+		mounts, _ := parseMounts(mountsEntry.Text)
+		env, _ := parseEnvList(envEntry.Text)
+		c.runContainer(win, dockerCli, &RunOpt{
+			Image:      strings.TrimSpace(imageEntry.Text),
+			Entrypoint: entrypointEntry.Text,
+			Command:    commandEntry.Text,
+			Mounts:     mounts,
+			Env:        env,
+			Network:    strings.TrimSpace(networkEntry.Text),
+		})
+	}, win.win)
+	dlg.Resize(fyne.NewSize(500, 0))
+	dlg.Show()
+}
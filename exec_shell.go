@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+// defaultShells 未指定命令时依次探测的 shell
+var defaultShells = [][]string{
+	{"bash"},
+	{"sh"},
+	{"ash"},
+	{"busybox", "sh"},
+}
+
+// ExecSettings 在容器中执行命令时的设置，Docker 和 K8S 共用
+type ExecSettings struct {
+	Command string   `json:"execCommand,omitempty"` // 为空时自动探测 shell
+	User    string   `json:"execUser,omitempty"`    // 执行命令的用户，K8S 通过容器中的 su 切换
+	WorkDir string   `json:"workDir,omitempty"`
+	Env     []string `json:"env,omitempty"` // KEY=VALUE
+}
+
+// command 返回配置的命令，未配置时返回 nil
+func (s *ExecSettings) command() []string {
+	return strings.Fields(s.Command)
+}
+
+// parseEnvList 解析逗号或换行分隔的环境变量列表
+func parseEnvList(s string) ([]string, error) {
+	env := make([]string, 0)
+	for _, line := range strings.Split(strings.ReplaceAll(s, "\n", ","), ",") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if i := strings.Index(line, "="); i <= 0 {
+			return nil, fmt.Errorf("invalid env: %s, expected KEY=VALUE", line)
+		}
+		env = append(env, line)
+	}
+	return env, nil
+}
+
+// execSettingsForm 返回执行设置的表单项和保存方法，userHint 为用户输入框的提示
+func execSettingsForm(settings *ExecSettings, userHint string) ([]*widget.FormItem, func(*ExecSettings)) {
+	commandEntry := widget.NewEntry()
+	commandEntry.SetPlaceHolder("auto: bash, sh, ash, busybox sh")
+	userEntry := widget.NewEntry()
+	userEntry.SetPlaceHolder(userHint)
+	workDirEntry := widget.NewEntry()
+	workDirEntry.SetPlaceHolder("/")
+	envEntry := widget.NewEntry()
+	envEntry.MultiLine = true
+	envEntry.SetPlaceHolder("TERM=xterm-256color\nLANG=C.UTF-8")
+	envEntry.Validator = func(s string) error {
+		_, err := parseEnvList(s)
+		return err
+	}
+	if settings != nil {
+		commandEntry.Text = settings.Command
+		userEntry.Text = settings.User
+		workDirEntry.Text = settings.WorkDir
+		envEntry.Text = strings.Join(settings.Env, "\n")
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Command", commandEntry),
+		widget.NewFormItem("User", userEntry),
+		widget.NewFormItem("Work Dir", workDirEntry),
+		widget.NewFormItem("Env", envEntry),
+	}
+	return items, func(s *ExecSettings) {
+		s.Command = strings.TrimSpace(commandEntry.Text)
+		s.User = strings.TrimSpace(userEntry.Text)
+		s.WorkDir = strings.TrimSpace(workDirEntry.Text)
+		s.Env, _ = parseEnvList(envEntry.Text)
+	}
+}
+
+// detectShell 依次探测可用的 shell，probe 在容器中执行 shell 并在失败时返回错误
+func detectShell(probe func(shell []string) error) ([]string, error) {
+	errs := make([]error, 0, len(defaultShells))
+	for _, shell := range defaultShells {
+		err := probe(shell)
+		if err == nil {
+			return shell, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", strings.Join(shell, " "), err))
+	}
+	return nil, fmt.Errorf("no usable shell found: %w", errors.Join(errs...))
+}
+
+// probeCommand 返回探测 shell 时执行的命令
+func probeCommand(shell []string) []string {
+	return append(append([]string{}, shell...), "-c", "exit 0")
+}
+
+// shellQuote 使用单引号转义 shell 参数
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// wrapCommand 通过 shell 切换用户和工作目录、设置环境变量后再执行命令，用于不支持这些参数的 K8S exec
+// 指定用户时通过 su 切换，容器中需要有 su，非 root 用户运行时 su 会询问密码
+func wrapCommand(shell, command []string, user, workDir string, env []string) []string {
+	if user == "" && workDir == "" && len(env) == 0 {
+		return command
+	}
+	script := make([]string, 0, 2)
+	if workDir != "" {
+		script = append(script, "cd "+shellQuote(workDir))
+	}
+	args := make([]string, 0, len(env)+len(command)+1)
+	if len(env) > 0 {
+		args = append(args, "env")
+	}
+	for _, arg := range append(append([]string{}, env...), command...) {
+		args = append(args, shellQuote(arg))
+	}
+	script = append(script, "exec "+strings.Join(args, " "))
+	if user != "" {
+		// 在目标用户下执行同样的脚本，su 之后工作目录和环境变量才对新进程生效
+		inner := strings.Join(script, " && ")
+		script = []string{"exec su -s /bin/sh -c " + shellQuote(inner) + " " + shellQuote(user)}
+	}
+	return append(append([]string{}, shell...), "-c", strings.Join(script, " && "))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvList(t *testing.T) {
+	env, err := parseEnvList("FOO=bar, LANG=C.UTF-8\nEMPTY=\n")
+	if err != nil {
+		t.Fatalf("parseEnvList failed: %v", err)
+	}
+	if !reflect.DeepEqual(env, []string{"FOO=bar", "LANG=C.UTF-8", "EMPTY="}) {
+		t.Errorf("parseEnvList() = %v", env)
+	}
+	for _, s := range []string{"FOO", "=bar"} {
+		if _, err := parseEnvList(s); err == nil {
+			t.Errorf("parseEnvList(%q) should fail", s)
+		}
+	}
+}
+
+// TestDetectShell 测试按顺序探测 shell，例如 alpine 中没有 bash
+func TestDetectShell(t *testing.T) {
+	var probed []string
+	shell, err := detectShell(func(shell []string) error {
+		probed = append(probed, strings.Join(shell, " "))
+		if shell[0] == "ash" {
+			return nil
+		}
+		return errors.New("executable file not found")
+	})
+	if err != nil {
+		t.Fatalf("detectShell failed: %v", err)
+	}
+	if !reflect.DeepEqual(shell, []string{"ash"}) {
+		t.Errorf("detectShell() = %v", shell)
+	}
+	if !reflect.DeepEqual(probed, []string{"bash", "sh", "ash"}) {
+		t.Errorf("Probed %v", probed)
+	}
+
+	_, err = detectShell(func(shell []string) error {
+		return errors.New("executable file not found")
+	})
+	if err == nil || !strings.Contains(err.Error(), "busybox sh") {
+		t.Errorf("Expected error listing all shells, got %v", err)
+	}
+}
+
+func TestProbeCommand(t *testing.T) {
+	shell := []string{"busybox", "sh"}
+	if got := probeCommand(shell); !reflect.DeepEqual(got, []string{"busybox", "sh", "-c", "exit 0"}) {
+		t.Errorf("probeCommand() = %v", got)
+	}
+	if len(shell) != 2 {
+		t.Error("probeCommand should not modify the shell")
+	}
+}
+
+// TestWrapCommand 使用本地 sh 执行包装后的命令，验证工作目录、环境变量和引号转义
+func TestWrapCommand(t *testing.T) {
+	if got := wrapCommand([]string{"sh"}, []string{"bash"}, "", "", nil); !reflect.DeepEqual(got, []string{"bash"}) {
+		t.Errorf("Command without user, work dir and env should not be wrapped, got %v", got)
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	dir := t.TempDir()
+	cmd := wrapCommand([]string{"sh"}, []string{"sh", "-c", `printf '%s|%s' "$(pwd)" "$GREETING"`}, "", dir, []string{"GREETING=it's ok"})
+	out, err := exec.Command(cmd[0], cmd[1:]...).Output()
+	if err != nil {
+		t.Fatalf("Wrapped command failed: %v", err)
+	}
+	if want := dir + "|it's ok"; string(out) != want {
+		t.Errorf("Wrapped command output = %q, want %q", out, want)
+	}
+}
+
+// TestWrapCommandUser 测试通过 su 切换用户后仍然设置工作目录和环境变量
+func TestWrapCommandUser(t *testing.T) {
+	cmd := wrapCommand([]string{"sh"}, []string{"bash"}, "nobody", "", nil)
+	if len(cmd) != 3 || cmd[0] != "sh" || cmd[1] != "-c" || !strings.HasPrefix(cmd[2], "exec su -s /bin/sh -c ") || !strings.HasSuffix(cmd[2], " 'nobody'") {
+		t.Errorf("Unexpected wrapped command: %v", cmd)
+	}
+
+	// 只有 root 可以不输入密码切换用户
+	if _, err := exec.LookPath("su"); err != nil || os.Geteuid() != 0 {
+		t.Skip("su as root not available")
+	}
+	dir := t.TempDir()
+	cmd = wrapCommand([]string{"sh"}, []string{"sh", "-c", `printf '%s|%s|%s' "$(id -un)" "$(pwd)" "$GREETING"`}, "root", dir, []string{"GREETING=it's ok"})
+	out, err := exec.Command(cmd[0], cmd[1:]...).Output()
+	if err != nil {
+		t.Fatalf("Wrapped command failed: %v", err)
+	}
+	if want := "root|" + dir + "|it's ok"; string(out) != want {
+		t.Errorf("Wrapped command output = %q, want %q", out, want)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// k8sFiles 通过 exec 在容器中运行 tar 上传和下载文件，与 kubectl cp 相同，容器中需要有 tar
+type k8sFiles struct {
+	restCfg   *rest.Config
+	clientset kubernetes.Interface
+	execOpt   *ExecOpt
+	workDir   string // 配置的工作目录，为空时使用容器的工作目录
+}
+
+// tarUploadCommand 返回在容器中解压上传文件的命令
+func tarUploadCommand(dst string) []string {
+	return []string{"tar", "-xmf", "-", "-C", path.Dir(dst)}
+}
+
+// tarDownloadCommand 返回在容器中打包下载文件的命令
+func tarDownloadCommand(src string) []string {
+	return []string{"tar", "-cf", "-", "-C", path.Dir(src), path.Base(src)}
+}
+
+// stream 在容器中执行命令并连接标准输入输出，失败时错误中包含命令的标准错误输出
+func (f *k8sFiles) stream(ctx context.Context, command []string, stdin io.Reader, stdout io.Writer) error {
+	executor, err := execRequest(f.restCfg, f.clientset, f.execOpt, command, stdin != nil, false)
+	if err != nil {
+		return err
+	}
+	var stderr bytes.Buffer
+	err = executor.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdin:  stdin,
+		Stdout: stdout,
+		Stderr: &stderr,
+	})
+	if err != nil && stderr.Len() > 0 {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return err
+}
+
+func (f *k8sFiles) WorkDir(ctx context.Context) (string, error) {
+	if f.workDir != "" {
+		return f.workDir, nil
+	}
+	var stdout bytes.Buffer
+	if err := f.stream(ctx, []string{"pwd"}, nil, &stdout); err != nil {
+		return "", err
+	}
+	if dir := strings.TrimSpace(stdout.String()); path.IsAbs(dir) {
+		return dir, nil
+	}
+	return "/", nil
+}
+
+func (f *k8sFiles) Upload(ctx context.Context, dst string, src io.Reader, size int64, mode os.FileMode) error {
+	content := tarPipe(path.Base(dst), src, size, mode)
+	defer content.Close()
+	return f.stream(ctx, tarUploadCommand(dst), content, io.Discard)
+}
+
+func (f *k8sFiles) Download(ctx context.Context, src string) (io.ReadCloser, int64, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(f.stream(ctx, tarDownloadCommand(src), nil, writer))
+	}()
+	closeFn := func() error {
+		cancel()
+		return reader.Close()
+	}
+	file, header, err := untarFile(reader)
+	if err != nil {
+		closeFn()
+		return nil, 0, err
+	}
+	return &readCloser{Reader: file, close: closeFn}, header.Size, nil
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+// 调试容器的默认镜像
+const defaultDebugImage = "busybox"
+
+// 等待调试容器启动的间隔和超时，拉取镜像可能需要较长时间
+const (
+	debugPollInterval = time.Second
+	debugWaitTimeout  = 2 * time.Minute
+)
+
+// DebugOpt 调试容器的参数
+type DebugOpt struct {
+	Image  string
+	Target string // 共享进程命名空间的目标容器
+}
+
+// debugContainerName 生成与现有容器不重名的调试容器名称
+func debugContainerName(pod *corev1.Pod) string {
+	exists := make(map[string]bool)
+	for _, c := range pod.Spec.Containers {
+		exists[c.Name] = true
+	}
+	for _, c := range pod.Spec.InitContainers {
+		exists[c.Name] = true
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		exists[c.Name] = true
+	}
+	for {
+		name := "debugger-" + utilrand.String(5)
+		if !exists[name] {
+			return name
+		}
+	}
+}
+
+// addDebugContainer 通过 ephemeralcontainers 子资源向 Pod 添加调试容器，返回容器名称
+func addDebugContainer(ctx context.Context, clientset kubernetes.Interface, namespace, podName string, opt DebugOpt) (string, error) {
+	pods := clientset.CoreV1().Pods(namespace)
+	pod, err := pods.Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	name := debugContainerName(pod)
+	pod = pod.DeepCopy()
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{
+			Name:                     name,
+			Image:                    opt.Image,
+			ImagePullPolicy:          corev1.PullIfNotPresent,
+			Stdin:                    true,
+			TTY:                      true,
+			TerminationMessagePolicy: corev1.TerminationMessageReadFile,
+		},
+		TargetContainerName: opt.Target,
+	})
+	if _, err := pods.UpdateEphemeralContainers(ctx, podName, pod, metav1.UpdateOptions{}); err != nil {
+		return "", fmt.Errorf("failed to add debug container: %w", err)
+	}
+	return name, nil
+}
+
+// waitForEphemeralContainer 等待调试容器进入运行状态，容器退出或拉取镜像失败时返回错误
+func waitForEphemeralContainer(ctx context.Context, clientset kubernetes.Interface, namespace, podName, container string, interval, timeout time.Duration) error {
+	err := wait.PollUntilContextTimeout(ctx, interval, timeout, true, func(ctx context.Context) (bool, error) {
+		pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		for _, status := range pod.Status.EphemeralContainerStatuses {
+			if status.Name != container {
+				continue
+			}
+			switch {
+			case status.State.Running != nil:
+				return true, nil
+			case status.State.Terminated != nil:
+				return false, fmt.Errorf("debug container %s terminated: %s", container, status.State.Terminated.Reason)
+			case status.State.Waiting != nil && isImagePullError(status.State.Waiting.Reason):
+				return false, fmt.Errorf("debug container %s: %s: %s", container, status.State.Waiting.Reason, status.State.Waiting.Message)
+			}
+		}
+		return false, nil
+	})
+	if wait.Interrupted(err) {
+		return fmt.Errorf("timed out waiting for debug container %s", container)
+	}
+	return err
+}
+
+func isImagePullError(reason string) bool {
+	return reason == "ErrImagePull" || reason == "ImagePullBackOff" || reason == "InvalidImageName"
+}
+
+// attachRequest 构建 attach 请求，连接到容器的主进程
+func attachRequest(restCfg *rest.Config, clientset kubernetes.Interface, execOpt *ExecOpt) (remotecommand.Executor, error) {
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(execOpt.PodName).
+		Namespace(execOpt.Namespace).
+		SubResource("attach").
+		VersionedParams(&corev1.PodAttachOptions{
+			Container: execOpt.Container,
+			Stdin:     true,
+			Stdout:    true,
+			Stderr:    true,
+			TTY:       true,
+		}, scheme.ParameterCodec)
+	return remotecommand.NewSPDYExecutor(restCfg, "POST", req.URL())
+}
+
+// debug 添加调试容器，启动后打开终端标签页
+func (c *K8SConfig) debug(win *Window, restCfg *rest.Config, clientset kubernetes.Interface, execOpt *ExecOpt, opt DebugOpt) {
+	go func() {
+		ctx := context.Background()
+		name, err := addDebugContainer(ctx, clientset, execOpt.Namespace, execOpt.PodName, opt)
+		if err != nil {
+			win.showError(err)
+			return
+		}
+		err = waitForEphemeralContainer(ctx, clientset, execOpt.Namespace, execOpt.PodName, name, debugPollInterval, debugWaitTimeout)
+		if err != nil {
+			win.showError(err)
+			return
+		}
+		debugOpt := &ExecOpt{Namespace: execOpt.Namespace, PodName: execOpt.PodName, Container: name}
+		executor, err := attachRequest(restCfg, clientset, debugOpt)
+		if err != nil {
+			win.showError(err)
+			return
+		}
+		fyne.Do(func() {
+			c.startTerm(win, executor, debugOpt, &k8sFiles{restCfg: restCfg, clientset: clientset, execOpt: debugOpt})
+		})
+	}()
+}
+
+// showDebugDialog 选择调试镜像和目标容器
+func (c *K8SConfig) showDebugDialog(win *Window, restCfg *rest.Config, clientset kubernetes.Interface, pod podSummary, container string) {
+	imageEntry := widget.NewEntry()
+	imageEntry.SetText(defaultDebugImage)
+	imageEntry.Validator = func(s string) error {
+		if strings.TrimSpace(s) == "" {
+			return fmt.Errorf("image is required")
+		}
+		return nil
+	}
+	targetSelect := widget.NewSelect(pod.Containers, nil)
+	targetSelect.PlaceHolder = "(none)"
+	targetSelect.SetSelected(container)
+
+	dlg := dialog.NewForm("Debug: "+pod.Name, "Start", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Image", imageEntry),
+		widget.NewFormItem("Target", targetSelect),
+	}, func(b bool) {
+		if !b {
+			return
+		}
+		c.debug(win, restCfg, clientset, &ExecOpt{Namespace: pod.Namespace, PodName: pod.Name}, DebugOpt{
+			Image:  strings.TrimSpace(imageEntry.Text),
+			Target: targetSelect.Selected,
+		})
+	}, win.win)
+	dlg.Resize(fyne.NewSize(400, 0))
+	dlg.Show()
+}
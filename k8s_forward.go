@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// 端口转发的目标类型
+const (
+	ForwardTargetPod     = "Pod"
+	ForwardTargetService = "Service"
+)
+
+// 端口转发只监听本机地址
+const k8sForwardAddress = "127.0.0.1"
+
+// PortForwardOpt 端口转发的参数，LocalPort 为 0 时随机分配
+type PortForwardOpt struct {
+	Kind       string
+	Namespace  string
+	Name       string
+	RemotePort int
+	LocalPort  int
+}
+
+func (o PortForwardOpt) String() string {
+	kind := "pod"
+	if o.Kind == ForwardTargetService {
+		kind = "svc"
+	}
+	return fmt.Sprintf("%s/%s/%s:%d", o.Namespace, kind, o.Name, o.RemotePort)
+}
+
+// podForService 返回服务选择器匹配的 Pod，优先选择运行中的 Pod
+func podForService(ctx context.Context, clientset kubernetes.Interface, namespace, name string) (*corev1.Service, *corev1.Pod, error) {
+	svc, err := clientset.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return nil, nil, fmt.Errorf("service %s has no selector", name)
+	}
+	list, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	pods := list.Items
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].Name < pods[j].Name
+	})
+	for i := range pods {
+		if pods[i].Status.Phase == corev1.PodRunning && pods[i].DeletionTimestamp == nil {
+			return svc, &pods[i], nil
+		}
+	}
+	return nil, nil, fmt.Errorf("no running pod found for service %s", name)
+}
+
+// servicePortTarget 将服务端口转换为 Pod 的容器端口，支持命名的 targetPort
+func servicePortTarget(svc *corev1.Service, pod *corev1.Pod, port int) (int, error) {
+	for _, sp := range svc.Spec.Ports {
+		if int(sp.Port) != port {
+			continue
+		}
+		if sp.TargetPort.Type == intstr.String {
+			for _, c := range pod.Spec.Containers {
+				for _, cp := range c.Ports {
+					if cp.Name == sp.TargetPort.StrVal {
+						return int(cp.ContainerPort), nil
+					}
+				}
+			}
+			return 0, fmt.Errorf("pod %s has no port named %s", pod.Name, sp.TargetPort.StrVal)
+		}
+		if sp.TargetPort.IntVal == 0 {
+			return port, nil
+		}
+		return int(sp.TargetPort.IntVal), nil
+	}
+	return 0, fmt.Errorf("service %s does not expose port %d", svc.Name, port)
+}
+
+// resolveForwardTarget 返回实际转发的 Pod 名称和端口，服务会被解析为后端的 Pod
+func resolveForwardTarget(ctx context.Context, clientset kubernetes.Interface, opt PortForwardOpt) (string, int, error) {
+	if opt.Kind != ForwardTargetService {
+		return opt.Name, opt.RemotePort, nil
+	}
+	svc, pod, err := podForService(ctx, clientset, opt.Namespace, opt.Name)
+	if err != nil {
+		return "", 0, err
+	}
+	port, err := servicePortTarget(svc, pod, opt.RemotePort)
+	if err != nil {
+		return "", 0, err
+	}
+	return pod.Name, port, nil
+}
+
+// K8SForward 一条正在运行的 K8S 端口转发
+type K8SForward struct {
+	Session string
+	Opt     PortForwardOpt
+	Pod     string // 实际转发的 Pod
+	Local   string // 本地监听地址
+
+	started  time.Time
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	done     chan struct{} // 转发结束后、调用 onStop 前关闭
+}
+
+func (f *K8SForward) Title() string {
+	target := f.Opt.String()
+	if f.Opt.Kind == ForwardTargetService {
+		target += " (" + f.Pod + ")"
+	}
+	return fmt.Sprintf("%s  %s -> %s", f.Session, f.Local, target)
+}
+
+func (f *K8SForward) Status() string {
+	return "up " + formatAge(time.Since(f.started))
+}
+
+func (f *K8SForward) Close() error {
+	f.stopOnce.Do(func() {
+		close(f.stopCh)
+	})
+	return nil
+}
+
+// startPortForward 通过 SPDY 启动端口转发，在本地端口开始监听后返回
+// onStop 在转发结束后调用，例如 Pod 被删除或连接断开
+func startPortForward(restCfg *rest.Config, clientset kubernetes.Interface, session string, opt PortForwardOpt, onStop func(*K8SForward, error)) (*K8SForward, error) {
+	pod, port, err := resolveForwardTarget(context.Background(), clientset, opt)
+	if err != nil {
+		return nil, err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(restCfg)
+	if err != nil {
+		return nil, err
+	}
+	req := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(opt.Namespace).
+		Name(pod).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	f := &K8SForward{Session: session, Opt: opt, Pod: pod, stopCh: make(chan struct{}), done: make(chan struct{})}
+	readyCh := make(chan struct{})
+	ports := []string{fmt.Sprintf("%d:%d", opt.LocalPort, port)}
+	fw, err := portforward.NewOnAddresses(dialer, []string{k8sForwardAddress}, ports, f.stopCh, readyCh, io.Discard, io.Discard)
+	if err != nil {
+		return nil, err
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- fw.ForwardPorts()
+	}()
+	select {
+	case err := <-errCh:
+		if err == nil {
+			err = errors.New("port forward stopped")
+		}
+		return nil, fmt.Errorf("failed to forward %s: %w", opt, err)
+	case <-readyCh:
+	}
+
+	forwarded, err := fw.GetPorts()
+	if err != nil || len(forwarded) == 0 {
+		f.Close()
+		return nil, fmt.Errorf("failed to get local port of %s: %v", opt, err)
+	}
+	f.Local = fmt.Sprintf("%s:%d", k8sForwardAddress, forwarded[0].Local)
+	f.started = time.Now()
+	go func() {
+		err := <-errCh
+		if err != nil {
+			log.Printf("Port forward %s stopped: %v", opt, err)
+		}
+		close(f.done)
+		if onStop != nil {
+			onStop(f, err)
+		}
+	}()
+	return f, nil
+}
+
+// forward 启动端口转发并登记到转发面板
+func (c *K8SConfig) forward(win *Window, restCfg *rest.Config, clientset kubernetes.Interface, opt PortForwardOpt) {
+	go func() {
+		f, err := startPortForward(restCfg, clientset, c.Name(), opt, func(f *K8SForward, err error) {
+			c.removeForward(f)
+			win.removeTunnels(f)
+		})
+		if err != nil {
+			win.showError(err)
+			return
+		}
+		// onStop 可能在登记前执行，检查和登记都在锁内，onStop 的移除会等待登记完成
+		c.forwardLock.Lock()
+		select {
+		case <-f.done:
+			c.forwardLock.Unlock()
+			return
+		default:
+		}
+		c.forwards = append(c.forwards, f)
+		win.addTunnels(f)
+		c.forwardLock.Unlock()
+		log.Printf("Forwarding %s -> %s", f.Local, opt)
+	}()
+}
+
+func (c *K8SConfig) removeForward(f *K8SForward) {
+	c.forwardLock.Lock()
+	defer c.forwardLock.Unlock()
+	for i, forward := range c.forwards {
+		if forward == f {
+			c.forwards = append(c.forwards[:i], c.forwards[i+1:]...)
+			return
+		}
+	}
+}
+
+// Close 停止该配置启动的所有端口转发
+func (c *K8SConfig) Close() error {
+	c.forwardLock.Lock()
+	forwards := append([]*K8SForward{}, c.forwards...)
+	c.forwardLock.Unlock()
+	for _, f := range forwards {
+		f.Close()
+	}
+	return nil
+}
+
+// containerPorts 返回 Pod 中声明的容器端口
+func containerPorts(pod *corev1.Pod) []string {
+	ports := make([]string, 0)
+	for _, c := range pod.Spec.Containers {
+		for _, p := range c.Ports {
+			if p.Protocol == "" || p.Protocol == corev1.ProtocolTCP {
+				ports = append(ports, strconv.Itoa(int(p.ContainerPort)))
+			}
+		}
+	}
+	return ports
+}
+
+// showPortForwardDialog 选择转发目标和端口，pod 为选择器中选中的 Pod
+func (c *K8SConfig) showPortForwardDialog(win *Window, restCfg *rest.Config, clientset kubernetes.Interface, pod podSummary) {
+	kindSelect := widget.NewRadioGroup([]string{ForwardTargetPod, ForwardTargetService}, nil)
+	kindSelect.Horizontal = true
+	nameEntry := widget.NewSelectEntry([]string{})
+	remoteEntry := widget.NewSelectEntry([]string{})
+	remoteEntry.Validator = func(s string) error {
+		if port, err := strconv.Atoi(s); err != nil || port <= 0 || port > 65535 {
+			return fmt.Errorf("invalid port: %s", s)
+		}
+		return nil
+	}
+	localEntry := widget.NewEntry()
+	localEntry.SetPlaceHolder("random")
+	localEntry.Validator = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		return checkPort(s)
+	}
+
+	// 切换目标类型时加载可选的 Pod 或服务
+	kindSelect.OnChanged = func(kind string) {
+		go func() {
+			names := make([]string, 0)
+			ctx := context.Background()
+			if kind == ForwardTargetService {
+				list, err := clientset.CoreV1().Services(pod.Namespace).List(ctx, metav1.ListOptions{})
+				if err == nil {
+					for _, svc := range list.Items {
+						names = append(names, svc.Name)
+					}
+				}
+			} else {
+				names = append(names, pod.Name)
+			}
+			fyne.Do(func() {
+				nameEntry.SetOptions(names)
+				if len(names) > 0 {
+					nameEntry.SetText(names[0])
+				} else {
+					nameEntry.SetText("")
+				}
+			})
+		}()
+	}
+	// 修改目标时加载声明的端口
+	nameEntry.OnChanged = func(name string) {
+		kind := kindSelect.Selected
+		go func() {
+			ports := make([]string, 0)
+			ctx := context.Background()
+			if kind == ForwardTargetService {
+				if svc, err := clientset.CoreV1().Services(pod.Namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+					for _, sp := range svc.Spec.Ports {
+						ports = append(ports, strconv.Itoa(int(sp.Port)))
+					}
+				}
+			} else if p, err := clientset.CoreV1().Pods(pod.Namespace).Get(ctx, name, metav1.GetOptions{}); err == nil {
+				ports = containerPorts(p)
+			}
+			fyne.Do(func() {
+				remoteEntry.SetOptions(ports)
+				if len(ports) > 0 && remoteEntry.Text == "" {
+					remoteEntry.SetText(ports[0])
+				}
+			})
+		}()
+	}
+	kindSelect.SetSelected(ForwardTargetPod)
+
+	dlg := dialog.NewForm("Port Forward", "Start", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Namespace", widget.NewLabel(pod.Namespace)),
+		widget.NewFormItem("Target", kindSelect),
+		widget.NewFormItem("Name", nameEntry),
+		widget.NewFormItem("Remote Port", remoteEntry),
+		widget.NewFormItem("Local Port", localEntry),
+	}, func(b bool) {
+		if !b {
+			return
+		}
+		remotePort, _ := strconv.Atoi(remoteEntry.Text)
+		localPort, _ := strconv.Atoi(localEntry.Text)
+		c.forward(win, restCfg, clientset, PortForwardOpt{
+			Kind:       kindSelect.Selected,
+			Namespace:  pod.Namespace,
+			Name:       nameEntry.Text,
+			RemotePort: remotePort,
+			LocalPort:  localPort,
+		})
+	}, win.win)
+	dlg.Resize(fyne.NewSize(400, 0))
+	dlg.Show()
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// defaultKubeconfigPath 返回默认的 kubeconfig 路径，KUBECONFIG 包含多个文件时取第一个
+func defaultKubeconfigPath() string {
+	if paths := filepath.SplitList(os.Getenv(clientcmd.RecommendedConfigPathEnvVar)); len(paths) > 0 && paths[0] != "" {
+		return paths[0]
+	}
+	return clientcmd.RecommendedHomeFile
+}
+
+// kubeconfigContexts 返回 kubeconfig 中的所有 context 名称和当前 context
+func kubeconfigContexts(path string) ([]string, string, error) {
+	cfg, err := clientcmd.LoadFromFile(expandHome(path))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load kubeconfig %s: %w", path, err)
+	}
+	contexts := make([]string, 0, len(cfg.Contexts))
+	for name := range cfg.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	return contexts, cfg.CurrentContext, nil
+}
+
+// restConfig 构建访问集群的配置
+// 指定 kubeconfig 时通过 clientcmd 加载，支持客户端证书、CA 和 exec 凭证插件
+func (d *K8SConfigData) restConfig() (*rest.Config, error) {
+	if d.Kubeconfig != "" {
+		rules := &clientcmd.ClientConfigLoadingRules{ExplicitPath: expandHome(d.Kubeconfig)}
+		overrides := &clientcmd.ConfigOverrides{CurrentContext: d.Context}
+		if d.InsecureTLS {
+			overrides.ClusterInfo.InsecureSkipTLSVerify = true
+		}
+		cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, overrides).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig %s: %w", d.Kubeconfig, err)
+		}
+		return cfg, nil
+	}
+
+	// 获取解密后的token
+	token, err := d.getToken()
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := d.tlsClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	return &rest.Config{
+		Host:            d.Server,
+		BearerToken:     token,
+		BearerTokenFile: "",
+		TLSClientConfig: tlsConfig,
+	}, nil
+}
+
+// tlsClientConfig 构建 TLS 配置，使用自定义 CA 校验服务端证书，客户端证书和私钥需要同时提供
+func (d *K8SConfigData) tlsClientConfig() (rest.TLSClientConfig, error) {
+	tlsConfig := rest.TLSClientConfig{
+		Insecure: d.InsecureTLS, // 使用配置的设置，默认false
+	}
+	if d.CAData != "" {
+		if d.InsecureTLS {
+			return tlsConfig, errors.New("CA certificate can not be used with skip TLS verification")
+		}
+		if err := validateCAData(d.CAData); err != nil {
+			return tlsConfig, err
+		}
+		tlsConfig.CAData = []byte(d.CAData)
+	}
+
+	key, err := d.getClientKey()
+	if err != nil {
+		return tlsConfig, err
+	}
+	if d.ClientCert == "" && key == "" {
+		return tlsConfig, nil
+	}
+	if d.ClientCert == "" || key == "" {
+		return tlsConfig, errors.New("client certificate and client key must be provided together")
+	}
+	if _, err := tls.X509KeyPair([]byte(d.ClientCert), []byte(key)); err != nil {
+		return tlsConfig, fmt.Errorf("invalid client certificate or key: %w", err)
+	}
+	tlsConfig.CertData = []byte(d.ClientCert)
+	tlsConfig.KeyData = []byte(key)
+	return tlsConfig, nil
+}
+
+// validateCAData 校验 PEM 格式的 CA 证书，空值表示使用系统证书
+func validateCAData(s string) error {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	if !x509.NewCertPool().AppendCertsFromPEM([]byte(s)) {
+		return errors.New("no valid PEM certificate found")
+	}
+	return nil
+}
+
+// showImportKubeconfigDialog 将 kubeconfig 中的 context 导入为 K8S 配置，每个 context 一条
+func (w *Window) showImportKubeconfigDialog() {
+	var contexts []string
+	selected := make(map[string]bool)
+
+	pathEntry := widget.NewEntry()
+	pathEntry.SetText(defaultKubeconfigPath())
+	list := widget.NewList(func() int {
+		return len(contexts)
+	}, func() fyne.CanvasObject {
+		return widget.NewCheck("", nil)
+	}, func(id widget.ListItemID, object fyne.CanvasObject) {
+		check := object.(*widget.Check)
+		name := contexts[id]
+		check.OnChanged = nil
+		check.SetChecked(selected[name])
+		check.OnChanged = func(b bool) {
+			selected[name] = b
+		}
+		// 已存在同名配置时不能导入
+		if w.findConfig(name) != nil {
+			check.SetText(name + " (exists)")
+			check.Disable()
+		} else {
+			check.SetText(name)
+			check.Enable()
+		}
+	})
+	load := func() {
+		var err error
+		contexts, _, err = kubeconfigContexts(pathEntry.Text)
+		if err != nil {
+			w.showError(err)
+		}
+		selected = make(map[string]bool)
+		list.Refresh()
+	}
+	loadButton := widget.NewButton("Load", load)
+	selectAll := widget.NewCheck("Select All", func(b bool) {
+		for _, name := range contexts {
+			selected[name] = b && w.findConfig(name) == nil
+		}
+		list.Refresh()
+	})
+	top := container.NewVBox(container.NewBorder(nil, nil, nil, loadButton, pathEntry), selectAll)
+	content := container.NewBorder(top, nil, nil, nil, list)
+
+	dlg := dialog.NewCustomConfirm("Import Kubeconfig", "Import", "Cancel", content, func(b bool) {
+		if !b {
+			return
+		}
+		imported := 0
+		for _, name := range contexts {
+			if !selected[name] || w.findConfig(name) != nil {
+				continue
+			}
+			w.confs = append(w.confs, &K8SConfig{data: &K8SConfigData{
+				Name:       name,
+				Type:       "k8s",
+				Kubeconfig: pathEntry.Text,
+				Context:    name,
+			}})
+			imported++
+		}
+		if imported > 0 {
+			w.save()
+		}
+	}, w.win)
+	dlg.Resize(fyne.NewSize(500, 400))
+	dlg.Show()
+	load()
+}
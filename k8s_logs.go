@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// podLogOptions 将界面参数转换为 PodLogOptions
+func podLogOptions(container string, opt *LogOpt, now time.Time) (*corev1.PodLogOptions, error) {
+	opts := &corev1.PodLogOptions{
+		Container:  container,
+		Follow:     opt.Follow,
+		Previous:   opt.Previous,
+		Timestamps: opt.Timestamps,
+	}
+	lines, ok, err := opt.tailLines()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		opts.TailLines = &lines
+	}
+	d, t, err := opt.since(now)
+	if err != nil {
+		return nil, err
+	}
+	if d > 0 {
+		seconds := int64(d.Seconds())
+		opts.SinceSeconds = &seconds
+	} else if !t.IsZero() {
+		since := metav1.NewTime(t)
+		opts.SinceTime = &since
+	}
+	return opts, nil
+}
+
+// showLogsDialog 选择日志参数后打开日志标签页
+func (c *K8SConfig) showLogsDialog(win *Window, clientset kubernetes.Interface, execOpt *ExecOpt) {
+	items, optFromForm := logOptForm(true)
+	items = append([]*widget.FormItem{widget.NewFormItem("Container", widget.NewLabel(execOpt.Container))}, items...)
+	dlg := dialog.NewForm("Logs: "+execOpt.PodName, "Open", "Cancel", items, func(b bool) {
+		if b {
+			c.logs(win, clientset, execOpt, optFromForm())
+		}
+	}, win.win)
+	dlg.Resize(fyne.NewSize(400, 0))
+	dlg.Show()
+}
+
+// logs 打开只读的日志标签页，关闭标签页时停止读取
+func (c *K8SConfig) logs(win *Window, clientset kubernetes.Interface, execOpt *ExecOpt, opt *LogOpt) {
+	opts, err := podLogOptions(execOpt.Container, opt, time.Now())
+	if err != nil {
+		win.showError(err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	term := NewTerm("logs: "+execOpt.PodName, c)
+	term.AddCloseListener(cancel)
+
+	go func() {
+		stream, err := clientset.CoreV1().Pods(execOpt.Namespace).GetLogs(execOpt.PodName, opts).Stream(ctx)
+		if err != nil {
+			cancel()
+			win.showError(err)
+			return
+		}
+		defer stream.Close()
+		fyne.Do(func() {
+			win.AddTermTab(term)
+		})
+		err = term.RunWithReaderAndWriter(readOnlyInput{}, &crlfReader{r: stream})
+		if err != nil {
+			log.Println(err)
+		}
+	}()
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// 分页查询时每页的数量
+const k8sPageSize = 200
+
+// 命名空间选择框中表示所有命名空间的选项
+const allNamespaces = "(all namespaces)"
+
+// podSummary 选择 Pod 时显示的摘要信息
+type podSummary struct {
+	Namespace  string
+	Name       string
+	Phase      string
+	Ready      string // 就绪容器数，例如 1/2
+	Node       string
+	Created    time.Time
+	Containers []string
+}
+
+// summarizePod 提取 Pod 的摘要信息
+func summarizePod(pod *corev1.Pod) podSummary {
+	ready := 0
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready {
+			ready++
+		}
+	}
+	phase := string(pod.Status.Phase)
+	if pod.DeletionTimestamp != nil {
+		phase = "Terminating"
+	}
+	containers := make([]string, 0, len(pod.Spec.Containers))
+	for _, c := range pod.Spec.Containers {
+		containers = append(containers, c.Name)
+	}
+	return podSummary{
+		Namespace:  pod.Namespace,
+		Name:       pod.Name,
+		Phase:      phase,
+		Ready:      fmt.Sprintf("%d/%d", ready, len(pod.Spec.Containers)),
+		Node:       pod.Spec.NodeName,
+		Created:    pod.CreationTimestamp.Time,
+		Containers: containers,
+	}
+}
+
+// formatAge 以 kubectl 的风格格式化时长，例如 45s、12m、3h、5d
+func formatAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// listNamespaces 分页列出所有命名空间
+func listNamespaces(ctx context.Context, clientset kubernetes.Interface) ([]string, error) {
+	namespaces := make([]string, 0)
+	opts := metav1.ListOptions{Limit: k8sPageSize}
+	for {
+		list, err := clientset.CoreV1().Namespaces().List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, ns := range list.Items {
+			namespaces = append(namespaces, ns.Name)
+		}
+		if list.Continue == "" {
+			break
+		}
+		opts.Continue = list.Continue
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// listPods 按标签选择器分页列出所有 Pod，namespace 为空时查询所有命名空间
+func listPods(ctx context.Context, clientset kubernetes.Interface, namespace, selector string) ([]podSummary, error) {
+	pods := make([]podSummary, 0)
+	opts := metav1.ListOptions{LabelSelector: selector, Limit: k8sPageSize}
+	for {
+		list, err := clientset.CoreV1().Pods(namespace).List(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			pods = append(pods, summarizePod(&list.Items[i]))
+		}
+		if list.Continue == "" {
+			break
+		}
+		opts.Continue = list.Continue
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, nil
+}
+
+// filterPods 按名称子串过滤 Pod，忽略大小写
+func filterPods(pods []podSummary, name string) []podSummary {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return pods
+	}
+	filtered := make([]podSummary, 0)
+	for _, pod := range pods {
+		if strings.Contains(strings.ToLower(pod.Name), name) {
+			filtered = append(filtered, pod)
+		}
+	}
+	return filtered
+}
+
+// podAction 选择 Pod 和容器后可执行的操作，例如 exec、查看日志
+type podAction struct {
+	Name string
+	Icon fyne.Resource
+	Run  func(pod podSummary, container string)
+}
+
+// showPodPicker 显示可搜索的 Pod 列表，选择 Pod 和容器后执行对应的操作
+func (w *Window) showPodPicker(title string, clientset kubernetes.Interface, actions ...podAction) {
+	var pods, visible []podSummary
+	var selected *podSummary
+
+	namespaceSelect := widget.NewSelect([]string{}, nil)
+	selectorEntry := widget.NewEntry()
+	selectorEntry.SetPlaceHolder("app=nginx,tier!=cache")
+	filterEntry := widget.NewEntry()
+	filterEntry.SetPlaceHolder("Filter by name")
+	containerSelect := widget.NewSelect([]string{}, nil)
+	status := widget.NewLabel("")
+
+	list := widget.NewList(func() int {
+		return len(visible)
+	}, func() fyne.CanvasObject {
+		name := widget.NewLabel("")
+		name.Truncation = fyne.TextTruncateEllipsis
+		return container.NewBorder(nil, nil, nil, widget.NewLabel(""), name)
+	}, func(id widget.ListItemID, object fyne.CanvasObject) {
+		box := object.(*fyne.Container)
+		name := box.Objects[0].(*widget.Label)
+		info := box.Objects[1].(*widget.Label)
+
+		pod := visible[id]
+		if namespaceSelect.Selected == allNamespaces {
+			name.SetText(pod.Namespace + "/" + pod.Name)
+		} else {
+			name.SetText(pod.Name)
+		}
+		info.SetText(fmt.Sprintf("%-10s %5s  %s  %s", pod.Phase, pod.Ready, pod.Node, formatAge(time.Since(pod.Created))))
+	})
+	list.OnSelected = func(id widget.ListItemID) {
+		pod := visible[id]
+		selected = &pod
+		containerSelect.SetOptions(pod.Containers)
+		if len(pod.Containers) > 0 {
+			containerSelect.SetSelectedIndex(0)
+		}
+	}
+	list.OnUnselected = func(id widget.ListItemID) {
+		selected = nil
+		containerSelect.ClearSelected()
+		containerSelect.SetOptions([]string{})
+	}
+
+	applyFilter := func() {
+		visible = filterPods(pods, filterEntry.Text)
+		selected = nil
+		list.UnselectAll()
+		list.Refresh()
+		status.SetText(fmt.Sprintf("%d of %d pods", len(visible), len(pods)))
+	}
+	filterEntry.OnChanged = func(string) {
+		applyFilter()
+	}
+	// generation 在每次加载时递增，只使用最后一次加载的结果，避免较慢的旧请求覆盖新结果
+	var generation int
+	cancelLoad := func() {}
+	reload := func() {
+		namespace := namespaceSelect.Selected
+		if namespace == "" {
+			return
+		}
+		if namespace == allNamespaces {
+			namespace = metav1.NamespaceAll
+		}
+		selector := selectorEntry.Text
+		status.SetText("Loading...")
+		cancelLoad()
+		ctx, cancel := context.WithCancel(context.Background())
+		cancelLoad = cancel
+		generation++
+		current := generation
+		go func() {
+			result, err := listPods(ctx, clientset, namespace, selector)
+			fyne.Do(func() {
+				if current != generation {
+					return
+				}
+				if err != nil {
+					status.SetText("")
+					w.showError(err)
+					return
+				}
+				pods = result
+				applyFilter()
+			})
+		}()
+	}
+	namespaceSelect.OnChanged = func(string) {
+		reload()
+	}
+	selectorEntry.OnSubmitted = func(string) {
+		reload()
+	}
+
+	go func() {
+		namespaces, err := listNamespaces(context.Background(), clientset)
+		if err != nil {
+			w.showError(err)
+			return
+		}
+		fyne.Do(func() {
+			namespaceSelect.SetOptions(append([]string{allNamespaces}, namespaces...))
+			namespaceSelect.SetSelected(metav1.NamespaceDefault)
+		})
+	}()
+
+	form := widget.NewForm(
+		widget.NewFormItem("Namespace", namespaceSelect),
+		widget.NewFormItem("Labels", container.NewBorder(nil, nil, nil, widget.NewButton("Search", reload), selectorEntry)),
+		widget.NewFormItem("Name", filterEntry),
+	)
+	bottom := widget.NewForm(widget.NewFormItem("Container", containerSelect))
+	content := container.NewBorder(form, container.NewVBox(status, bottom), nil, nil, list)
+
+	dlg := dialog.NewCustomWithoutButtons(title, content, w.win)
+	buttons := []fyne.CanvasObject{widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), dlg.Hide)}
+	for _, action := range actions {
+		button := widget.NewButtonWithIcon(action.Name, action.Icon, func() {
+			if selected == nil {
+				return
+			}
+			dlg.Hide()
+			action.Run(*selected, containerSelect.Selected)
+		})
+		button.Importance = widget.HighImportance
+		buttons = append(buttons, button)
+	}
+	dlg.SetButtons(buttons)
+	dlg.Resize(fyne.NewSize(700, 500))
+	dlg.Show()
+}
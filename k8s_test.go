@@ -0,0 +1,602 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+const testKubeconfig = `apiVersion: v1
+kind: Config
+current-context: dev
+clusters:
+- name: dev-cluster
+  cluster:
+    server: https://dev.example.com:6443
+- name: prod-cluster
+  cluster:
+    server: https://prod.example.com:6443
+users:
+- name: dev-user
+  user:
+    token: dev-token
+- name: prod-user
+  user:
+    token: prod-token
+contexts:
+- name: dev
+  context:
+    cluster: dev-cluster
+    user: dev-user
+- name: prod
+  context:
+    cluster: prod-cluster
+    user: prod-user
+    namespace: default
+`
+
+func writeTestKubeconfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(testKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestKubeconfigContexts(t *testing.T) {
+	path := writeTestKubeconfig(t)
+	contexts, current, err := kubeconfigContexts(path)
+	if err != nil {
+		t.Fatalf("kubeconfigContexts failed: %v", err)
+	}
+	if !reflect.DeepEqual(contexts, []string{"dev", "prod"}) || current != "dev" {
+		t.Errorf("kubeconfigContexts() = %v %s", contexts, current)
+	}
+	if _, _, err := kubeconfigContexts(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing kubeconfig")
+	}
+}
+
+func TestK8SRestConfigFromKubeconfig(t *testing.T) {
+	path := writeTestKubeconfig(t)
+	tests := []struct {
+		context string
+		host    string
+		token   string
+	}{
+		{"", "https://dev.example.com:6443", "dev-token"},
+		{"prod", "https://prod.example.com:6443", "prod-token"},
+	}
+	for _, tt := range tests {
+		data := &K8SConfigData{Kubeconfig: path, Context: tt.context, InsecureTLS: true}
+		cfg, err := data.restConfig()
+		if err != nil {
+			t.Fatalf("restConfig(%q) failed: %v", tt.context, err)
+		}
+		if cfg.Host != tt.host || cfg.BearerToken != tt.token || !cfg.Insecure {
+			t.Errorf("restConfig(%q) = %s %s insecure=%v", tt.context, cfg.Host, cfg.BearerToken, cfg.Insecure)
+		}
+	}
+
+	data := &K8SConfigData{Kubeconfig: path, Context: "missing"}
+	if _, err := data.restConfig(); err == nil {
+		t.Error("Expected error for missing context")
+	}
+}
+
+func TestK8SRestConfigFromToken(t *testing.T) {
+	data := &K8SConfigData{Server: "https://k8s.example.com"}
+	if _, err := data.setToken("secret-token"); err != nil {
+		t.Fatalf("setToken failed: %v", err)
+	}
+	cfg, err := data.restConfig()
+	if err != nil {
+		t.Fatalf("restConfig failed: %v", err)
+	}
+	if cfg.Host != data.Server || cfg.BearerToken != "secret-token" {
+		t.Errorf("restConfig() = %s %s", cfg.Host, cfg.BearerToken)
+	}
+}
+
+// newTestClientCert 生成自签名的客户端证书和私钥
+func newTestClientCert(t *testing.T) (certPEM, keyPEM []byte, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "goshell-test"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ = x509.ParseCertificate(der)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	return
+}
+
+// TestK8STLSClientConfig 测试使用自定义 CA 校验服务端，并使用客户端证书认证
+func TestK8STLSClientConfig(t *testing.T) {
+	certPEM, keyPEM, clientCert := newTestClientCert(t)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(clientCert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"major":"1","minor":"34","gitVersion":"v1.34.1"}`))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	server.StartTLS()
+	defer server.Close()
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	serverVersion := func(data *K8SConfigData) error {
+		cfg, err := data.restConfig()
+		if err != nil {
+			return err
+		}
+		clientset, err := kubernetes.NewForConfig(cfg)
+		if err != nil {
+			return err
+		}
+		_, err = clientset.Discovery().ServerVersion()
+		return err
+	}
+
+	data := &K8SConfigData{Server: server.URL, CAData: string(caPEM), ClientCert: string(certPEM)}
+	if _, err := data.setClientKey(string(keyPEM)); err != nil {
+		t.Fatalf("setClientKey failed: %v", err)
+	}
+	if data.ClientKey == string(keyPEM) {
+		t.Error("Client key should be stored encrypted")
+	}
+	if err := serverVersion(data); err != nil {
+		t.Fatalf("Request with CA and client cert failed: %v", err)
+	}
+
+	// 没有 CA 时无法校验服务端证书
+	noCA := *data
+	noCA.CAData = ""
+	if err := serverVersion(&noCA); err == nil {
+		t.Error("Request without CA should fail")
+	}
+
+	// 没有客户端证书时服务端拒绝握手
+	noCert := &K8SConfigData{Server: server.URL, CAData: string(caPEM)}
+	if err := serverVersion(noCert); err == nil {
+		t.Error("Request without client cert should fail")
+	}
+}
+
+func TestK8STLSClientConfigValidation(t *testing.T) {
+	certPEM, _, _ := newTestClientCert(t)
+	tests := []struct {
+		name string
+		data *K8SConfigData
+	}{
+		{"invalid CA", &K8SConfigData{CAData: "not a certificate"}},
+		{"CA with insecure", &K8SConfigData{CAData: string(certPEM), InsecureTLS: true}},
+		{"cert without key", &K8SConfigData{ClientCert: string(certPEM)}},
+	}
+	for _, tt := range tests {
+		if _, err := tt.data.tlsClientConfig(); err == nil {
+			t.Errorf("%s: expected error", tt.name)
+		}
+	}
+}
+
+func newTestPod(namespace, name string, labels map[string]string, ready ...bool) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:         namespace,
+			Name:              name,
+			Labels:            labels,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-3 * time.Hour)),
+		},
+		Spec:   corev1.PodSpec{NodeName: "node-1"},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	for i, r := range ready {
+		containerName := fmt.Sprintf("c%d", i)
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: containerName})
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{Name: containerName, Ready: r})
+	}
+	return pod
+}
+
+func TestSummarizePod(t *testing.T) {
+	pod := newTestPod("default", "web-1", nil, true, false)
+	summary := summarizePod(pod)
+	if summary.Ready != "1/2" || summary.Phase != "Running" || summary.Node != "node-1" {
+		t.Errorf("summarizePod() = %+v", summary)
+	}
+	if !reflect.DeepEqual(summary.Containers, []string{"c0", "c1"}) {
+		t.Errorf("Containers = %v", summary.Containers)
+	}
+	now := metav1.Now()
+	pod.DeletionTimestamp = &now
+	if summary := summarizePod(pod); summary.Phase != "Terminating" {
+		t.Errorf("Phase = %s, want Terminating", summary.Phase)
+	}
+}
+
+func TestFormatAge(t *testing.T) {
+	tests := map[time.Duration]string{
+		30 * time.Second: "30s",
+		5 * time.Minute:  "5m",
+		3 * time.Hour:    "3h",
+		72 * time.Hour:   "3d",
+	}
+	for d, want := range tests {
+		if got := formatAge(d); got != want {
+			t.Errorf("formatAge(%v) = %s, want %s", d, got, want)
+		}
+	}
+}
+
+// TestListPodsPaging 测试按 Continue 分页获取所有 Pod，并传递标签选择器
+func TestListPodsPaging(t *testing.T) {
+	clientset := fake.NewClientset()
+	var requests []metav1.ListOptions
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		opts := action.(k8stesting.ListActionImpl).ListOptions
+		requests = append(requests, opts)
+		list := &corev1.PodList{}
+		switch opts.Continue {
+		case "":
+			list.Items = []corev1.Pod{*newTestPod("default", "web-2", map[string]string{"app": "web"}, true)}
+			list.Continue = "page-2"
+		case "page-2":
+			list.Items = []corev1.Pod{*newTestPod("default", "web-1", map[string]string{"app": "web"}, true)}
+		}
+		return true, list, nil
+	})
+
+	pods, err := listPods(context.Background(), clientset, "default", "app=web")
+	if err != nil {
+		t.Fatalf("listPods failed: %v", err)
+	}
+	if len(pods) != 2 || pods[0].Name != "web-1" || pods[1].Name != "web-2" {
+		t.Errorf("listPods() = %+v", pods)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("Expected 2 requests, got %d", len(requests))
+	}
+	for _, opts := range requests {
+		if opts.LabelSelector != "app=web" || opts.Limit != k8sPageSize {
+			t.Errorf("Unexpected list options: %+v", opts)
+		}
+	}
+}
+
+func TestFilterPods(t *testing.T) {
+	clientset := fake.NewClientset(
+		newTestPod("default", "web-1", map[string]string{"app": "web"}, true),
+		newTestPod("default", "Web-2", map[string]string{"app": "web"}, true),
+		newTestPod("default", "db-1", map[string]string{"app": "db"}, true),
+		newTestPod("other", "web-3", map[string]string{"app": "web"}, true),
+	)
+	pods, err := listPods(context.Background(), clientset, "default", "app=web")
+	if err != nil {
+		t.Fatalf("listPods failed: %v", err)
+	}
+	if len(pods) != 2 {
+		t.Fatalf("Expected 2 pods matching selector, got %d", len(pods))
+	}
+	if filtered := filterPods(pods, "web-2"); len(filtered) != 1 || filtered[0].Name != "Web-2" {
+		t.Errorf("filterPods() = %+v", filtered)
+	}
+	if filtered := filterPods(pods, " "); len(filtered) != 2 {
+		t.Errorf("Empty filter should keep all pods, got %d", len(filtered))
+	}
+
+	all, err := listPods(context.Background(), clientset, metav1.NamespaceAll, "")
+	if err != nil {
+		t.Fatalf("listPods failed: %v", err)
+	}
+	if len(all) != 4 || all[len(all)-1].Namespace != "other" {
+		t.Errorf("listPods(all) = %+v", all)
+	}
+}
+
+func TestPodLogOptions(t *testing.T) {
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	opts, err := podLogOptions("app", &LogOpt{Follow: true, TailLines: "100", Since: "10m", Previous: true, Timestamps: true}, now)
+	if err != nil {
+		t.Fatalf("podLogOptions failed: %v", err)
+	}
+	if opts.Container != "app" || !opts.Follow || !opts.Previous || !opts.Timestamps {
+		t.Errorf("podLogOptions() = %+v", opts)
+	}
+	if opts.TailLines == nil || *opts.TailLines != 100 {
+		t.Errorf("TailLines = %v", opts.TailLines)
+	}
+	if opts.SinceSeconds == nil || *opts.SinceSeconds != 600 || opts.SinceTime != nil {
+		t.Errorf("SinceSeconds = %v, SinceTime = %v", opts.SinceSeconds, opts.SinceTime)
+	}
+
+	opts, err = podLogOptions("app", &LogOpt{Since: "2026-10-18T11:00:00Z"}, now)
+	if err != nil {
+		t.Fatalf("podLogOptions failed: %v", err)
+	}
+	if opts.SinceTime == nil || !opts.SinceTime.Time.Equal(now.Add(-time.Hour)) || opts.TailLines != nil {
+		t.Errorf("SinceTime = %v, TailLines = %v", opts.SinceTime, opts.TailLines)
+	}
+
+	for _, opt := range []*LogOpt{
+		{TailLines: "-1"},
+		{TailLines: "abc"},
+		{Since: "yesterday"},
+		{Since: "-5m"},
+		{Since: "2026-10-19T00:00:00Z"},
+	} {
+		if _, err := podLogOptions("app", opt, now); err == nil {
+			t.Errorf("podLogOptions(%+v) should fail", opt)
+		}
+	}
+}
+
+// TestCRLFReader 测试日志换行转换，读取错误统一转换为 EOF
+func TestCRLFReader(t *testing.T) {
+	r := &crlfReader{r: strings.NewReader("line1\nline2\n")}
+	out, err := io.ReadAll(iotest.OneByteReader(r))
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if string(out) != "line1\r\nline2\r\n" {
+		t.Errorf("crlfReader output = %q", out)
+	}
+
+	r = &crlfReader{r: iotest.ErrReader(errors.New("stream reset"))}
+	if _, err := r.Read(make([]byte, 16)); err != io.EOF {
+		t.Errorf("Read error = %v, want EOF", err)
+	}
+}
+
+// TestPodLogsStream 测试通过 fake clientset 获取日志
+func TestPodLogsStream(t *testing.T) {
+	clientset := fake.NewClientset(newTestPod("default", "web-1", nil, true))
+	opts, err := podLogOptions("c0", &LogOpt{TailLines: "10"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := clientset.CoreV1().Pods("default").GetLogs("web-1", opts).Stream(context.Background())
+	if err != nil {
+		t.Fatalf("GetLogs failed: %v", err)
+	}
+	defer stream.Close()
+	out, err := io.ReadAll(&crlfReader{r: stream})
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(out) == 0 {
+		t.Error("Expected fake logs")
+	}
+}
+
+func newTestService(namespace, name string, selector map[string]string, ports ...corev1.ServicePort) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec:       corev1.ServiceSpec{Selector: selector, Ports: ports},
+	}
+}
+
+func TestServicePortTarget(t *testing.T) {
+	pod := newTestPod("default", "web-1", nil, true)
+	pod.Spec.Containers[0].Ports = []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}
+	svc := newTestService("default", "web", nil,
+		corev1.ServicePort{Port: 80, TargetPort: intstr.FromString("http")},
+		corev1.ServicePort{Port: 443, TargetPort: intstr.FromInt32(8443)},
+		corev1.ServicePort{Port: 9090},
+		corev1.ServicePort{Port: 81, TargetPort: intstr.FromString("admin")},
+	)
+
+	tests := map[int]int{80: 8080, 443: 8443, 9090: 9090}
+	for port, want := range tests {
+		got, err := servicePortTarget(svc, pod, port)
+		if err != nil || got != want {
+			t.Errorf("servicePortTarget(%d) = %d, %v, want %d", port, got, err, want)
+		}
+	}
+	if _, err := servicePortTarget(svc, pod, 81); err == nil {
+		t.Error("Expected error for unknown named port")
+	}
+	if _, err := servicePortTarget(svc, pod, 8000); err == nil {
+		t.Error("Expected error for unexposed port")
+	}
+}
+
+func TestResolveForwardTarget(t *testing.T) {
+	pending := newTestPod("default", "web-0", map[string]string{"app": "web"}, false)
+	pending.Status.Phase = corev1.PodPending
+	running := newTestPod("default", "web-1", map[string]string{"app": "web"}, true)
+	running.Spec.Containers[0].Ports = []corev1.ContainerPort{{Name: "http", ContainerPort: 8080}}
+	other := newTestPod("default", "db-0", map[string]string{"app": "db"}, true)
+	clientset := fake.NewClientset(pending, running, other,
+		newTestService("default", "web", map[string]string{"app": "web"}, corev1.ServicePort{Port: 80, TargetPort: intstr.FromString("http")}),
+		newTestService("default", "headless", nil, corev1.ServicePort{Port: 80}),
+		newTestService("default", "empty", map[string]string{"app": "none"}, corev1.ServicePort{Port: 80}),
+	)
+	ctx := context.Background()
+
+	pod, port, err := resolveForwardTarget(ctx, clientset, PortForwardOpt{Kind: ForwardTargetService, Namespace: "default", Name: "web", RemotePort: 80})
+	if err != nil || pod != "web-1" || port != 8080 {
+		t.Errorf("resolveForwardTarget(service) = %s, %d, %v", pod, port, err)
+	}
+	pod, port, err = resolveForwardTarget(ctx, clientset, PortForwardOpt{Kind: ForwardTargetPod, Namespace: "default", Name: "db-0", RemotePort: 5432})
+	if err != nil || pod != "db-0" || port != 5432 {
+		t.Errorf("resolveForwardTarget(pod) = %s, %d, %v", pod, port, err)
+	}
+	for _, name := range []string{"headless", "empty", "missing"} {
+		if _, _, err := resolveForwardTarget(ctx, clientset, PortForwardOpt{Kind: ForwardTargetService, Namespace: "default", Name: name, RemotePort: 80}); err == nil {
+			t.Errorf("resolveForwardTarget(%s) should fail", name)
+		}
+	}
+}
+
+func TestContainerPorts(t *testing.T) {
+	pod := newTestPod("default", "web-1", nil, true, true)
+	pod.Spec.Containers[0].Ports = []corev1.ContainerPort{{ContainerPort: 80}, {ContainerPort: 53, Protocol: corev1.ProtocolUDP}}
+	pod.Spec.Containers[1].Ports = []corev1.ContainerPort{{ContainerPort: 9090, Protocol: corev1.ProtocolTCP}}
+	if got := containerPorts(pod); !reflect.DeepEqual(got, []string{"80", "9090"}) {
+		t.Errorf("containerPorts() = %v", got)
+	}
+}
+
+func TestK8SConfigCloseStopsForwards(t *testing.T) {
+	c := &K8SConfig{data: &K8SConfigData{Name: "test"}}
+	f := &K8SForward{Session: "test", Opt: PortForwardOpt{Kind: ForwardTargetService, Namespace: "default", Name: "web", RemotePort: 80}, Pod: "web-1", Local: "127.0.0.1:8080", stopCh: make(chan struct{})}
+	c.forwards = append(c.forwards, f)
+	if got := f.Title(); got != "test  127.0.0.1:8080 -> default/svc/web:80 (web-1)" {
+		t.Errorf("Title() = %q", got)
+	}
+
+	c.Close()
+	select {
+	case <-f.stopCh:
+	default:
+		t.Fatal("Forward should be stopped")
+	}
+	// 重复关闭不应 panic
+	f.Close()
+}
+
+func TestAddDebugContainer(t *testing.T) {
+	pod := newTestPod("default", "app-1", nil, true)
+	clientset := fake.NewClientset(pod)
+	ctx := context.Background()
+
+	name, err := addDebugContainer(ctx, clientset, "default", "app-1", DebugOpt{Image: "busybox", Target: "c0"})
+	if err != nil {
+		t.Fatalf("addDebugContainer failed: %v", err)
+	}
+	if !strings.HasPrefix(name, "debugger-") {
+		t.Errorf("Unexpected debug container name: %s", name)
+	}
+
+	var updated bool
+	for _, action := range clientset.Actions() {
+		if action.GetVerb() == "update" && action.GetSubresource() == "ephemeralcontainers" {
+			updated = true
+		}
+	}
+	if !updated {
+		t.Error("Expected an update on the ephemeralcontainers subresource")
+	}
+
+	got, err := clientset.CoreV1().Pods("default").Get(ctx, "app-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Spec.EphemeralContainers) != 1 {
+		t.Fatalf("Expected 1 ephemeral container, got %d", len(got.Spec.EphemeralContainers))
+	}
+	ec := got.Spec.EphemeralContainers[0]
+	if ec.Name != name || ec.Image != "busybox" || ec.TargetContainerName != "c0" || !ec.TTY || !ec.Stdin {
+		t.Errorf("Unexpected ephemeral container: %+v", ec)
+	}
+
+	// 再次添加时名称不能重复
+	second, err := addDebugContainer(ctx, clientset, "default", "app-1", DebugOpt{Image: "busybox"})
+	if err != nil {
+		t.Fatalf("addDebugContainer failed: %v", err)
+	}
+	if second == name {
+		t.Errorf("Debug container name should be unique: %s", second)
+	}
+
+	if _, err := addDebugContainer(ctx, clientset, "default", "missing", DebugOpt{Image: "busybox"}); err == nil {
+		t.Error("Expected error for missing pod")
+	}
+}
+
+func TestWaitForEphemeralContainer(t *testing.T) {
+	setStatus := func(clientset *fake.Clientset, state corev1.ContainerState) {
+		pod, err := clientset.CoreV1().Pods("default").Get(context.Background(), "app-1", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pod.Status.EphemeralContainerStatuses = []corev1.ContainerStatus{{Name: "debugger-abcde", State: state}}
+		if _, err := clientset.CoreV1().Pods("default").UpdateStatus(context.Background(), pod, metav1.UpdateOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wait := func(clientset *fake.Clientset, timeout time.Duration) error {
+		return waitForEphemeralContainer(context.Background(), clientset, "default", "app-1", "debugger-abcde", 10*time.Millisecond, timeout)
+	}
+
+	clientset := fake.NewClientset(newTestPod("default", "app-1", nil, true))
+	setStatus(clientset, corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		setStatus(clientset, corev1.ContainerState{Running: &corev1.ContainerStateRunning{}})
+	}()
+	if err := wait(clientset, 5*time.Second); err != nil {
+		t.Errorf("waitForEphemeralContainer failed: %v", err)
+	}
+
+	setStatus(clientset, corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff", Message: "not found"}})
+	if err := wait(clientset, 5*time.Second); err == nil || !strings.Contains(err.Error(), "ImagePullBackOff") {
+		t.Errorf("Expected image pull error, got %v", err)
+	}
+
+	setStatus(clientset, corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error"}})
+	if err := wait(clientset, 5*time.Second); err == nil || !strings.Contains(err.Error(), "terminated") {
+		t.Errorf("Expected terminated error, got %v", err)
+	}
+
+	setStatus(clientset, corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ContainerCreating"}})
+	if err := wait(clientset, 50*time.Millisecond); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+}
+
+func TestTarCommands(t *testing.T) {
+	if got := tarUploadCommand("/etc/app/app.conf"); !reflect.DeepEqual(got, []string{"tar", "-xmf", "-", "-C", "/etc/app"}) {
+		t.Errorf("tarUploadCommand() = %v", got)
+	}
+	if got := tarDownloadCommand("/var/log/app.log"); !reflect.DeepEqual(got, []string{"tar", "-cf", "-", "-C", "/var/log", "app.log"}) {
+		t.Errorf("tarDownloadCommand() = %v", got)
+	}
+	if got := tarUploadCommand("/app.conf"); !reflect.DeepEqual(got, []string{"tar", "-xmf", "-", "-C", "/"}) {
+		t.Errorf("tarUploadCommand() = %v", got)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2/widget"
+)
+
+// 默认显示的日志行数
+const defaultLogTailLines = 500
+
+// LogOpt 查看日志的参数，K8S 和 Docker 共用
+type LogOpt struct {
+	Follow     bool
+	TailLines  string // 为空时显示全部日志
+	Since      string // 时长（例如 10m）或 RFC3339 时间
+	Previous   bool   // 查看上一次退出的容器日志，仅 K8S 支持
+	Timestamps bool
+}
+
+// tailLines 解析显示的行数，未设置时 ok 为 false
+func (o *LogOpt) tailLines() (lines int64, ok bool, err error) {
+	s := strings.TrimSpace(o.TailLines)
+	if s == "" {
+		return 0, false, nil
+	}
+	lines, err = strconv.ParseInt(s, 10, 64)
+	if err != nil || lines < 0 {
+		return 0, false, fmt.Errorf("invalid tail lines: %s", s)
+	}
+	return lines, true, nil
+}
+
+// since 解析日志的起始时间，时长和时间最多只有一个不为零值
+func (o *LogOpt) since(now time.Time) (time.Duration, time.Time, error) {
+	s := strings.TrimSpace(o.Since)
+	if s == "" {
+		return 0, time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		if d <= 0 {
+			return 0, time.Time{}, fmt.Errorf("invalid since duration: %s", s)
+		}
+		return d, time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("invalid since: %s, expected a duration like 10m or an RFC3339 time", s)
+	}
+	if t.After(now) {
+		return 0, time.Time{}, fmt.Errorf("since time is in the future: %s", s)
+	}
+	return 0, t, nil
+}
+
+// logOptForm 返回日志参数的表单项和读取方法，withPrevious 为 false 时不显示上一个容器选项
+func logOptForm(withPrevious bool) ([]*widget.FormItem, func() *LogOpt) {
+	followCheck := widget.NewCheck("Follow", nil)
+	followCheck.SetChecked(true)
+	tailEntry := widget.NewEntry()
+	tailEntry.SetText(strconv.Itoa(defaultLogTailLines))
+	tailEntry.SetPlaceHolder("all")
+	sinceEntry := widget.NewEntry()
+	sinceEntry.SetPlaceHolder("10m or 2006-01-02T15:04:05Z")
+	previousCheck := widget.NewCheck("Previous container", nil)
+	timestampsCheck := widget.NewCheck("Timestamps", nil)
+
+	tailEntry.Validator = func(s string) error {
+		_, _, err := (&LogOpt{TailLines: s}).tailLines()
+		return err
+	}
+	sinceEntry.Validator = func(s string) error {
+		_, _, err := (&LogOpt{Since: s}).since(time.Now())
+		return err
+	}
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Tail Lines", tailEntry),
+		widget.NewFormItem("Since", sinceEntry),
+		widget.NewFormItem("", followCheck),
+	}
+	if withPrevious {
+		items = append(items, widget.NewFormItem("", previousCheck))
+	}
+	items = append(items, widget.NewFormItem("", timestampsCheck))
+	return items, func() *LogOpt {
+		return &LogOpt{
+			Follow:     followCheck.Checked,
+			TailLines:  tailEntry.Text,
+			Since:      sinceEntry.Text,
+			Previous:   withPrevious && previousCheck.Checked,
+			Timestamps: timestampsCheck.Checked,
+		}
+	}
+}
+
+// crlfReader 将日志中的 \n 转换为 \r\n，使终端正确换行
+// 终端只把 EOF 当作正常结束，其他读取错误记录日志后统一返回 EOF
+type crlfReader struct {
+	r   io.Reader
+	buf []byte
+}
+
+func (c *crlfReader) Read(p []byte) (int, error) {
+	if len(c.buf) == 0 {
+		chunk := make([]byte, len(p))
+		n, err := c.r.Read(chunk)
+		if n == 0 {
+			if err != nil && err != io.EOF {
+				if !errors.Is(err, context.Canceled) {
+					log.Printf("Failed to read logs: %v", err)
+				}
+				err = io.EOF
+			}
+			return 0, err
+		}
+		c.buf = bytes.ReplaceAll(chunk[:n], []byte("\n"), []byte("\r\n"))
+	}
+	n := copy(p, c.buf)
+	c.buf = c.buf[n:]
+	return n, nil
+}
+
+// readOnlyInput 丢弃终端中的输入，日志标签页是只读的
+type readOnlyInput struct{}
+
+func (readOnlyInput) Write(p []byte) (int, error) {
+	return len(p), nil
+}
+
+func (readOnlyInput) Close() error {
+	return nil
+}
+
+// eofReader 将读取错误统一转换为 EOF，终端只把 EOF 当作正常结束
+type eofReader struct {
+	r io.Reader
+}
+
+func (e eofReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil && err != io.EOF {
+		if !errors.Is(err, context.Canceled) && !errors.Is(err, net.ErrClosed) {
+			log.Printf("Failed to read output: %v", err)
+		}
+		err = io.EOF
+	}
+	return n, err
+}
@@ -0,0 +1,441 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// SFTPPanel 远程文件面板，与终端共用同一个 SSH 连接
+type SFTPPanel struct {
+	win      *Window
+	client   *sftp.Client
+	lock     sync.Mutex
+	cwd      string
+	entries  []os.FileInfo
+	selected int
+
+	pathLabel *widget.Label
+	list      *widget.List
+	content   fyne.CanvasObject
+}
+
+// NewSFTPPanel 在 SSH 连接上打开 SFTP 子系统
+func NewSFTPPanel(win *Window, conn *ssh.Client) (*SFTPPanel, error) {
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sftp subsystem: %w", err)
+	}
+	cwd, err := client.Getwd()
+	if err != nil {
+		cwd = "/"
+	}
+	p := &SFTPPanel{win: win, client: client, cwd: cwd, selected: -1}
+	return p, nil
+}
+
+// Content 返回面板界面，需要在主线程调用
+func (p *SFTPPanel) Content() fyne.CanvasObject {
+	if p.content != nil {
+		return p.content
+	}
+	p.pathLabel = widget.NewLabel(p.cwd)
+	p.pathLabel.Truncation = fyne.TextTruncateEllipsis
+	p.list = widget.NewList(func() int {
+		return len(p.entries)
+	}, func() fyne.CanvasObject {
+		return container.NewHBox(widget.NewIcon(theme.FileIcon()), widget.NewLabel(""), layout.NewSpacer(), widget.NewLabel(""))
+	}, func(id widget.ListItemID, object fyne.CanvasObject) {
+		box := object.(*fyne.Container)
+		icon := box.Objects[0].(*widget.Icon)
+		name := box.Objects[1].(*widget.Label)
+		info := box.Objects[3].(*widget.Label)
+
+		entry := p.entries[id]
+		name.SetText(entry.Name())
+		if entry.IsDir() {
+			icon.SetResource(theme.FolderIcon())
+			info.SetText(entry.Mode().String())
+		} else {
+			icon.SetResource(theme.FileIcon())
+			info.SetText(fmt.Sprintf("%s  %s", entry.Mode().String(), formatBytes(entry.Size())))
+		}
+	})
+	p.list.OnSelected = func(id widget.ListItemID) {
+		p.selected = id
+	}
+	p.list.OnUnselected = func(id widget.ListItemID) {
+		p.selected = -1
+	}
+
+	toolbar := widget.NewToolbar(
+		widget.NewToolbarAction(theme.MoveUpIcon(), func() {
+			p.open(path.Dir(p.cwd))
+		}),
+		widget.NewToolbarAction(theme.FolderOpenIcon(), func() {
+			if entry := p.selectedEntry(); entry != nil && entry.IsDir() {
+				p.open(path.Join(p.cwd, entry.Name()))
+			}
+		}),
+		widget.NewToolbarAction(theme.ViewRefreshIcon(), func() {
+			p.open(p.cwd)
+		}),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.UploadIcon(), p.upload),
+		widget.NewToolbarAction(theme.DownloadIcon(), p.download),
+		widget.NewToolbarSeparator(),
+		widget.NewToolbarAction(theme.FolderNewIcon(), p.mkdir),
+		widget.NewToolbarAction(theme.DocumentCreateIcon(), p.rename),
+		widget.NewToolbarAction(theme.SettingsIcon(), p.chmod),
+		widget.NewToolbarAction(theme.DeleteIcon(), p.remove),
+	)
+	p.content = container.NewBorder(container.NewVBox(toolbar, p.pathLabel), nil, nil, nil, p.list)
+	p.open(p.cwd)
+	return p.content
+}
+
+// Close 关闭 SFTP 会话
+func (p *SFTPPanel) Close() error {
+	return p.sftpClient().Close()
+}
+
+// Reconnect 在新的 SSH 连接上重新打开 SFTP 会话，保留当前目录
+func (p *SFTPPanel) Reconnect(conn *ssh.Client) error {
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		return fmt.Errorf("failed to open sftp subsystem: %w", err)
+	}
+	p.lock.Lock()
+	old := p.client
+	p.client = client
+	p.lock.Unlock()
+	old.Close()
+	fyne.Do(func() {
+		if p.content != nil {
+			p.open(p.cwd)
+		}
+	})
+	return nil
+}
+
+func (p *SFTPPanel) sftpClient() *sftp.Client {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.client
+}
+
+func (p *SFTPPanel) selectedEntry() os.FileInfo {
+	if p.selected < 0 || p.selected >= len(p.entries) {
+		return nil
+	}
+	return p.entries[p.selected]
+}
+
+// open 切换到指定目录并刷新列表
+func (p *SFTPPanel) open(dir string) {
+	go func() {
+		entries, err := listRemoteDir(p.sftpClient(), dir)
+		if err != nil {
+			p.win.showError(err)
+			return
+		}
+		fyne.Do(func() {
+			p.cwd = dir
+			p.entries = entries
+			p.selected = -1
+			p.pathLabel.SetText(dir)
+			p.list.UnselectAll()
+			p.list.Refresh()
+		})
+	}()
+}
+
+// listRemoteDir 列出远程目录，目录排在文件前面，同类按名称排序
+func listRemoteDir(client *sftp.Client, dir string) ([]os.FileInfo, error) {
+	entries, err := client.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].IsDir() != entries[j].IsDir() {
+			return entries[i].IsDir()
+		}
+		return entries[i].Name() < entries[j].Name()
+	})
+	return entries, nil
+}
+
+func (p *SFTPPanel) upload() {
+	dlg := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
+		if err != nil {
+			p.win.showError(err)
+			return
+		}
+		if reader == nil {
+			return
+		}
+		var size int64 = -1
+		if info, err := os.Stat(reader.URI().Path()); err == nil {
+			size = info.Size()
+		}
+		dst := path.Join(p.cwd, reader.URI().Name())
+		go func() {
+			defer reader.Close()
+			file, err := p.sftpClient().Create(dst)
+			if err != nil {
+				p.win.showError(err)
+				return
+			}
+			defer file.Close()
+			if err := p.win.transfer("Uploading "+reader.URI().Name(), file, reader, size); err != nil && !errors.Is(err, errTransferCanceled) {
+				p.win.showError(err)
+			}
+			p.refresh()
+		}()
+	}, p.win.win)
+	dlg.Show()
+}
+
+func (p *SFTPPanel) download() {
+	entry := p.selectedEntry()
+	if entry == nil || entry.IsDir() {
+		p.win.showError(errors.New("please select a file to download"))
+		return
+	}
+	src := path.Join(p.cwd, entry.Name())
+	dlg := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil {
+			p.win.showError(err)
+			return
+		}
+		if writer == nil {
+			return
+		}
+		go func() {
+			defer writer.Close()
+			file, err := p.sftpClient().Open(src)
+			if err != nil {
+				p.win.showError(err)
+				return
+			}
+			defer file.Close()
+			if err := p.win.transfer("Downloading "+entry.Name(), writer, file, entry.Size()); err != nil && !errors.Is(err, errTransferCanceled) {
+				p.win.showError(err)
+			}
+		}()
+	}, p.win.win)
+	dlg.SetFileName(entry.Name())
+	dlg.Show()
+}
+
+func (p *SFTPPanel) mkdir() {
+	nameEntry := widget.NewEntry()
+	dialog.ShowForm("New Folder", "OK", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+	}, func(b bool) {
+		if b && nameEntry.Text != "" {
+			dir := p.cwd
+			p.run(func() error {
+				return p.sftpClient().Mkdir(path.Join(dir, nameEntry.Text))
+			})
+		}
+	}, p.win.win)
+}
+
+func (p *SFTPPanel) rename() {
+	entry := p.selectedEntry()
+	if entry == nil {
+		return
+	}
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(entry.Name())
+	dialog.ShowForm("Rename", "OK", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Name", nameEntry),
+	}, func(b bool) {
+		if b && nameEntry.Text != "" && nameEntry.Text != entry.Name() {
+			dir := p.cwd
+			p.run(func() error {
+				return p.sftpClient().Rename(path.Join(dir, entry.Name()), path.Join(dir, nameEntry.Text))
+			})
+		}
+	}, p.win.win)
+}
+
+func (p *SFTPPanel) chmod() {
+	entry := p.selectedEntry()
+	if entry == nil {
+		return
+	}
+	modeEntry := widget.NewEntry()
+	modeEntry.SetText(strconv.FormatUint(uint64(entry.Mode().Perm()), 8))
+	modeEntry.Validator = func(s string) error {
+		_, err := parseFileMode(s)
+		return err
+	}
+	dialog.ShowForm("Change Mode: "+entry.Name(), "OK", "Cancel", []*widget.FormItem{
+		widget.NewFormItem("Mode", modeEntry),
+	}, func(b bool) {
+		if b {
+			mode, err := parseFileMode(modeEntry.Text)
+			if err != nil {
+				p.win.showError(err)
+				return
+			}
+			target := path.Join(p.cwd, entry.Name())
+			p.run(func() error {
+				return p.sftpClient().Chmod(target, mode)
+			})
+		}
+	}, p.win.win)
+}
+
+func (p *SFTPPanel) remove() {
+	entry := p.selectedEntry()
+	if entry == nil {
+		return
+	}
+	dialog.ShowConfirm("Delete",
+		fmt.Sprintf("Are you sure you want to delete '%s'?", entry.Name()),
+		func(confirmed bool) {
+			if confirmed {
+				target := path.Join(p.cwd, entry.Name())
+				p.run(func() error {
+					if entry.IsDir() {
+						return p.sftpClient().RemoveAll(target)
+					}
+					return p.sftpClient().Remove(target)
+				})
+			}
+		}, p.win.win)
+}
+
+// run 在后台执行远程操作，完成后刷新列表
+// cwd 只在主线程中读写，fn 中使用的路径需要在调用前确定
+func (p *SFTPPanel) run(fn func() error) {
+	go func() {
+		if err := fn(); err != nil {
+			p.win.showError(err)
+		}
+		p.refresh()
+	}()
+}
+
+// refresh 在主线程中读取当前目录并刷新列表，可以在后台调用
+func (p *SFTPPanel) refresh() {
+	fyne.Do(func() {
+		p.open(p.cwd)
+	})
+}
+
+// parseFileMode 解析八进制权限，例如 644、0755
+func parseFileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0o7777 {
+		return 0, fmt.Errorf("invalid file mode: %s", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// progressWriter 记录已写入的字节数
+type progressWriter struct {
+	w       io.Writer
+	written atomic.Int64
+}
+
+func (p *progressWriter) Write(b []byte) (int, error) {
+	n, err := p.w.Write(b)
+	p.written.Add(int64(n))
+	return n, err
+}
+
+// errTransferCanceled 用户取消传输
+var errTransferCanceled = errors.New("transfer canceled")
+
+// cancelableReader 取消后读取返回错误，用于中断 io.Copy
+type cancelableReader struct {
+	r        io.Reader
+	canceled atomic.Bool
+}
+
+func (c *cancelableReader) Read(b []byte) (int, error) {
+	if c.canceled.Load() {
+		return 0, errTransferCanceled
+	}
+	return c.r.Read(b)
+}
+
+// transfer 复制数据并显示进度，size 小于 0 时显示不确定进度，不能在主线程中调用
+func (w *Window) transfer(title string, dst io.Writer, src io.Reader, size int64) error {
+	progress := &progressWriter{w: dst}
+	reader := &cancelableReader{r: src}
+
+	// 控件只在主线程中创建和访问
+	var dlg dialog.Dialog
+	var bar *widget.ProgressBar
+	var label *widget.Label
+	fyne.Do(func() {
+		label = widget.NewLabel("")
+		var indicator fyne.CanvasObject
+		if size > 0 {
+			bar = widget.NewProgressBar()
+			indicator = bar
+		} else {
+			indicator = widget.NewProgressBarInfinite()
+		}
+		dlg = dialog.NewCustom(title, "Cancel", container.NewVBox(indicator, label), w.win)
+		dlg.SetOnClosed(func() {
+			reader.canceled.Store(true)
+		})
+		dlg.Resize(fyne.NewSize(400, 150))
+		dlg.Show()
+	})
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(200 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				written := progress.written.Load()
+				fyne.Do(func() {
+					if bar != nil {
+						bar.SetValue(float64(written) / float64(size))
+						label.SetText(fmt.Sprintf("%s / %s", formatBytes(written), formatBytes(size)))
+					} else {
+						label.SetText(formatBytes(written))
+					}
+				})
+			}
+		}
+	}()
+
+	_, err := io.Copy(progress, reader)
+	close(done)
+	fyne.Do(func() {
+		dlg.Hide()
+	})
+	if err != nil {
+		log.Printf("%s: %v", title, err)
+	}
+	return err
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+)
+
+// newTestSFTPClient 创建连接到内存 SFTP 服务的客户端
+func newTestSFTPClient(t *testing.T) *sftp.Client {
+	t.Helper()
+	clientReader, serverWriter := io.Pipe()
+	serverReader, clientWriter := io.Pipe()
+	server := sftp.NewRequestServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{serverReader, serverWriter}, sftp.InMemHandler())
+	go server.Serve()
+
+	client, err := sftp.NewClientPipe(clientReader, clientWriter)
+	if err != nil {
+		t.Fatalf("NewClientPipe failed: %v", err)
+	}
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+	return client
+}
+
+// TestListRemoteDir 测试远程目录排序
+func TestListRemoteDir(t *testing.T) {
+	client := newTestSFTPClient(t)
+	for _, dir := range []string{"/data/zeta", "/data/alpha"} {
+		if err := client.MkdirAll(dir); err != nil {
+			t.Fatalf("MkdirAll failed: %v", err)
+		}
+	}
+	for _, name := range []string{"/data/b.txt", "/data/a.txt"} {
+		file, err := client.Create(name)
+		if err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		file.Write([]byte("content"))
+		file.Close()
+	}
+
+	entries, err := listRemoteDir(client, "/data")
+	if err != nil {
+		t.Fatalf("listRemoteDir failed: %v", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if got := strings.Join(names, ","); got != "alpha,zeta,a.txt,b.txt" {
+		t.Errorf("Unexpected order: %s", got)
+	}
+}
+
+// TestParseFileMode 测试八进制权限解析
+func TestParseFileMode(t *testing.T) {
+	mode, err := parseFileMode("0755")
+	if err != nil || mode != os.FileMode(0755) {
+		t.Errorf("parseFileMode(0755) got=%v err=%v", mode, err)
+	}
+	mode, err = parseFileMode("644")
+	if err != nil || mode != os.FileMode(0644) {
+		t.Errorf("parseFileMode(644) got=%v err=%v", mode, err)
+	}
+	for _, invalid := range []string{"", "abc", "789", "17777"} {
+		if _, err := parseFileMode(invalid); err == nil {
+			t.Errorf("parseFileMode(%q) should fail", invalid)
+		}
+	}
+}
+
+// TestTransferHelpers 测试传输进度统计和取消
+func TestTransferHelpers(t *testing.T) {
+	var buf bytes.Buffer
+	progress := &progressWriter{w: &buf}
+	reader := &cancelableReader{r: strings.NewReader("hello world")}
+	if _, err := io.Copy(progress, reader); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	if progress.written.Load() != 11 || buf.String() != "hello world" {
+		t.Errorf("Unexpected progress: %d %q", progress.written.Load(), buf.String())
+	}
+
+	reader = &cancelableReader{r: strings.NewReader("data")}
+	reader.canceled.Store(true)
+	if _, err := io.Copy(io.Discard, reader); !errors.Is(err, errTransferCanceled) {
+		t.Errorf("Expected canceled error, got %v", err)
+	}
+}
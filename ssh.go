@@ -2,13 +2,16 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/widget"
 	"golang.org/x/crypto/ssh"
-	"golang.org/x/crypto/ssh/knownhosts"
+	"golang.org/x/crypto/ssh/agent"
 	"log"
-	"os"
-	"path/filepath"
+	"net"
 	"strconv"
+	"strings"
 )
 
 type SSHConfigData struct {
@@ -18,6 +21,22 @@ type SSHConfigData struct {
 	Port int    `json:"port,omitempty"`
 	User string `json:"user,omitempty"`
 	Pswd string `json:"pswd,omitempty"` // 加密存储
+
+	KeyPath    string   `json:"keyPath,omitempty"`
+	CertPath   string   `json:"certPath,omitempty"`   // OpenSSH 用户证书，与私钥配合使用
+	Key        string   `json:"key,omitempty"`        // 内联私钥，加密存储
+	Passphrase string   `json:"passphrase,omitempty"` // 私钥口令，加密存储
+	AuthOrder  []string `json:"authOrder,omitempty"`  // 认证顺序
+
+	UseAgent     bool `json:"useAgent,omitempty"`     // 使用 ssh-agent 认证
+	ForwardAgent bool `json:"forwardAgent,omitempty"` // 转发 ssh-agent 到远程会话
+
+	JumpHosts []string `json:"jumpHosts,omitempty"` // 跳板机配置名称，按连接顺序排列
+
+	Forwards []ForwardRule `json:"forwards,omitempty"` // 端口转发规则，会话建立后自动启动
+
+	KeepAlive     int  `json:"keepAlive,omitempty"`     // 心跳间隔（秒），0 使用默认值，小于 0 关闭
+	AutoReconnect bool `json:"autoReconnect,omitempty"` // 连接中断后自动重连
 }
 
 // getPassword 返回解密后的密码
@@ -79,6 +98,32 @@ func (c *SSHConfig) Form() *widget.Form {
 	portEntry := widget.NewEntry()
 	userEntry := widget.NewEntry()
 	pswdEntry := widget.NewEntry()
+	keyPathEntry := widget.NewEntry()
+	certPathEntry := widget.NewEntry()
+	keyEntry := widget.NewEntry()
+	passphraseEntry := widget.NewEntry()
+	authOrderEntry := widget.NewEntry()
+	useAgentCheck := widget.NewCheck("Use SSH Agent", func(b bool) {})
+	forwardAgentCheck := widget.NewCheck("Forward Agent", func(b bool) {})
+	jumpHostsEntry := widget.NewEntry()
+	jumpHostsEntry.SetPlaceHolder("bastion1,bastion2")
+	forwardsEntry := widget.NewEntry()
+	forwardsEntry.MultiLine = true
+	forwardsEntry.SetPlaceHolder("L 8080:localhost:80\nR 9000:localhost:3000\nD 1080")
+	forwardsEntry.Validator = func(s string) error {
+		_, err := parseForwardRules(s)
+		return err
+	}
+	keepAliveEntry := widget.NewEntry()
+	keepAliveEntry.SetPlaceHolder("30 (seconds, -1 to disable)")
+	keepAliveEntry.Validator = func(s string) error {
+		if s == "" {
+			return nil
+		}
+		_, err := strconv.Atoi(s)
+		return err
+	}
+	autoReconnectCheck := widget.NewCheck("Auto Reconnect", func(b bool) {})
 
 	portEntry.Text = "22"
 	portEntry.Validator = func(s string) error {
@@ -86,6 +131,18 @@ func (c *SSHConfig) Form() *widget.Form {
 		return err
 	}
 	pswdEntry.Password = true
+	keyPathEntry.SetPlaceHolder("~/.ssh/id_ed25519")
+	certPathEntry.SetPlaceHolder("~/.ssh/id_ed25519-cert.pub")
+	keyEntry.MultiLine = true
+	keyEntry.Wrapping = fyne.TextWrapBreak
+	keyEntry.SetPlaceHolder("Paste PEM private key")
+	passphraseEntry.Password = true
+	// 为空时使用默认顺序
+	authOrderEntry.SetPlaceHolder(strings.Join(defaultAuthOrder, ","))
+	authOrderEntry.Validator = func(s string) error {
+		_, err := parseAuthOrder(s)
+		return err
+	}
 	data := c.data
 	isNewConfig := (data == nil)
 	if data != nil {
@@ -96,6 +153,17 @@ func (c *SSHConfig) Form() *widget.Form {
 		userEntry.Text = data.User
 		// 修改时显示空密码，用户需要重新输入
 		pswdEntry.Text = ""
+		keyPathEntry.Text = data.KeyPath
+		certPathEntry.Text = data.CertPath
+		authOrderEntry.Text = strings.Join(customAuthOrder(data.AuthOrder), ",")
+		useAgentCheck.SetChecked(data.UseAgent)
+		forwardAgentCheck.SetChecked(data.ForwardAgent)
+		jumpHostsEntry.Text = strings.Join(data.JumpHosts, ",")
+		forwardsEntry.Text = formatForwardRules(data.Forwards)
+		if data.KeepAlive != 0 {
+			keepAliveEntry.Text = strconv.Itoa(data.KeepAlive)
+		}
+		autoReconnectCheck.SetChecked(data.AutoReconnect)
 	}
 	c.onOk = func() {
 		if c.data == nil {
@@ -111,6 +179,27 @@ func (c *SSHConfig) Form() *widget.Form {
 				log.Printf("Failed to encrypt password: %v", err)
 			}
 		}
+		c.data.KeyPath = keyPathEntry.Text
+		c.data.CertPath = certPathEntry.Text
+		// 内联私钥和口令与密码相同，为空时保持原值
+		if keyEntry.Text != "" || isNewConfig {
+			if _, err := c.data.setKey(keyEntry.Text); err != nil {
+				log.Printf("Failed to encrypt private key: %v", err)
+			}
+		}
+		if passphraseEntry.Text != "" || isNewConfig {
+			if _, err := c.data.setPassphrase(passphraseEntry.Text); err != nil {
+				log.Printf("Failed to encrypt passphrase: %v", err)
+			}
+		}
+		authOrder, _ := parseAuthOrder(authOrderEntry.Text)
+		c.data.AuthOrder = customAuthOrder(authOrder)
+		c.data.UseAgent = useAgentCheck.Checked
+		c.data.ForwardAgent = forwardAgentCheck.Checked
+		c.data.JumpHosts = splitList(jumpHostsEntry.Text)
+		c.data.Forwards, _ = parseForwardRules(forwardsEntry.Text)
+		c.data.KeepAlive, _ = strconv.Atoi(keepAliveEntry.Text)
+		c.data.AutoReconnect = autoReconnectCheck.Checked
 	}
 	return widget.NewForm([]*widget.FormItem{
 		widget.NewFormItem("Name", nameEntry),
@@ -118,6 +207,16 @@ func (c *SSHConfig) Form() *widget.Form {
 		widget.NewFormItem("Port", portEntry),
 		widget.NewFormItem("Username", userEntry),
 		widget.NewFormItem("Password", pswdEntry),
+		widget.NewFormItem("Key File", keyPathEntry),
+		widget.NewFormItem("Certificate", certPathEntry),
+		widget.NewFormItem("Private Key", keyEntry),
+		widget.NewFormItem("Passphrase", passphraseEntry),
+		widget.NewFormItem("Auth Order", authOrderEntry),
+		widget.NewFormItem("Agent", container.NewHBox(useAgentCheck, forwardAgentCheck)),
+		widget.NewFormItem("Jump Hosts", jumpHostsEntry),
+		widget.NewFormItem("Forwards", forwardsEntry),
+		widget.NewFormItem("Keep Alive", keepAliveEntry),
+		widget.NewFormItem("Reconnect", autoReconnectCheck),
 	}...)
 }
 
@@ -125,89 +224,33 @@ func (c *SSHConfig) OnOk() {
 	c.onOk()
 }
 
-func (c *SSHConfig) Term(win *Window) {
-	conf := c.data
+// addr 返回主机地址
+func (c *SSHConfig) addr() string {
+	return net.JoinHostPort(c.data.Host, strconv.Itoa(c.data.Port))
+}
 
-	// 获取解密后的密码
-	password, err := conf.getPassword()
+// clientConfig 构建连接当前主机使用的客户端配置
+func (c *SSHConfig) clientConfig(win *Window, ag agent.Agent) (*ssh.ClientConfig, error) {
+	auth, err := c.authMethods(win, ag)
 	if err != nil {
-		log.Printf("Failed to decrypt password: %v", err)
-		win.showError(err)
-		return
+		return nil, fmt.Errorf("failed to prepare auth methods for %s: %w", c.Name(), err)
 	}
 
-	// 创建已知主机文件路径
-	knownHostsPath := filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts")
-
-	// 创建主机密钥回调函数
-	hostKeyCallback, err := knownhosts.New(knownHostsPath)
+	// 创建主机密钥回调函数，未知主机交由用户确认
+	hostKeyCallback, err := newHostKeyCallback(knownHostsPath(), win.confirmHostKey)
 	if err != nil {
-		log.Printf("Failed to create host key callback: %v", err)
-		win.showError(err)
-		return
+		return nil, err
 	}
 
-	cli := ssh.ClientConfig{
-		User:            conf.User,
-		Auth:            []ssh.AuthMethod{ssh.Password(password)},
+	return &ssh.ClientConfig{
+		User:            c.data.User,
+		Auth:            auth,
 		HostKeyCallback: hostKeyCallback,
-		Timeout:         10, // 10秒超时
-	}
-
-	addr := conf.Host + ":" + strconv.Itoa(conf.Port)
-	conn, err := ssh.Dial("tcp", addr, &cli)
-	if err != nil {
-		log.Println(err)
-		win.showError(err)
-		return
-	}
-	session, err := conn.NewSession()
-	if err != nil {
-		log.Println(err)
-		win.showError(err)
-		return
-	}
-	modes := ssh.TerminalModes{
-		ssh.ECHO:          0,     // disable echoing
-		ssh.TTY_OP_ISPEED: 14400, // input speed = 14.4kbaud
-		ssh.TTY_OP_OSPEED: 14400, // output speed = 14.4kbaud
-	}
-	err = session.RequestPty("xterm-color", 24, 80, modes)
-	if err != nil {
-		log.Println(err)
-		win.showError(err)
-		return
-	}
-	in, err := session.StdinPipe()
-	if err != nil {
-		log.Println(err)
-		win.showError(err)
-		return
-	}
-	out, err := session.StdoutPipe()
-	if err != nil {
-		log.Println(err)
-		win.showError(err)
-		return
-	}
-
-	term := NewTerm(conf.Name, c)
-
-	go func() {
-		defer session.Close()
-		err = term.RunWithReaderAndWriter(in, out)
-		if err != nil {
-			log.Println(err)
-		}
-		session.Close()
-	}()
-
-	go func() {
-		err := session.Shell()
-		if err != nil {
-			log.Println(err)
-		}
-	}()
+		Timeout:         sshDialTimeout,
+	}, nil
+}
 
-	win.AddTermTab(term)
+func (c *SSHConfig) Term(win *Window) {
+	// 认证过程中可能需要弹窗询问口令，不能阻塞主线程
+	go c.connect(win)
 }
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// SSH 认证方式
+const (
+	AuthKey      = "key"
+	AuthPassword = "password"
+	AuthAgent    = "agent"
+
+	AuthKeyboardInteractive = "keyboard-interactive"
+)
+
+// defaultAuthOrder 默认认证顺序：先密钥，后密码，最后交互式认证
+var defaultAuthOrder = []string{AuthKey, AuthPassword, AuthKeyboardInteractive}
+
+// parseAuthOrder 解析逗号分隔的认证顺序
+func parseAuthOrder(s string) ([]string, error) {
+	order := make([]string, 0)
+	for _, item := range strings.Split(s, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		switch item {
+		case AuthKey, AuthPassword, AuthAgent, AuthKeyboardInteractive:
+		default:
+			return nil, fmt.Errorf("unknown auth method: %s", item)
+		}
+		for _, exist := range order {
+			if exist == item {
+				return nil, fmt.Errorf("duplicate auth method: %s", item)
+			}
+		}
+		order = append(order, item)
+	}
+	return order, nil
+}
+
+// legacyDefaultAuthOrder 加入交互式认证之前的默认顺序，当时表单总是保存认证顺序，与其相同的配置视为使用默认顺序
+var legacyDefaultAuthOrder = []string{AuthKey, AuthPassword}
+
+// sameAuthOrder 判断两个认证顺序是否相同
+func sameAuthOrder(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// customAuthOrder 返回需要保存的认证顺序，与默认顺序相同时返回 nil，默认顺序变化后随之更新
+func customAuthOrder(order []string) []string {
+	if len(order) == 0 || sameAuthOrder(order, defaultAuthOrder) || sameAuthOrder(order, legacyDefaultAuthOrder) {
+		return nil
+	}
+	return order
+}
+
+// getKey 返回解密后的内联私钥
+func (d *SSHConfigData) getKey() (string, error) {
+	return decryptString(d.Key)
+}
+
+// setKey 加密并设置内联私钥
+func (d *SSHConfigData) setKey(key string) (string, error) {
+	encrypted, err := encryptString(key)
+	if err != nil {
+		return "", err
+	}
+	d.Key = encrypted
+	return encrypted, nil
+}
+
+// getPassphrase 返回解密后的私钥口令
+func (d *SSHConfigData) getPassphrase() (string, error) {
+	return decryptString(d.Passphrase)
+}
+
+// setPassphrase 加密并设置私钥口令
+func (d *SSHConfigData) setPassphrase(passphrase string) (string, error) {
+	encrypted, err := encryptString(passphrase)
+	if err != nil {
+		return "", err
+	}
+	d.Passphrase = encrypted
+	return encrypted, nil
+}
+
+// authOrder 返回配置的认证顺序，未配置或与默认顺序相同时使用当前的默认顺序
+// 启用 ssh-agent 但顺序中未指定时，优先尝试 agent
+func (d *SSHConfigData) authOrder() []string {
+	order := customAuthOrder(d.AuthOrder)
+	if len(order) == 0 {
+		order = defaultAuthOrder
+	}
+	if d.UseAgent {
+		for _, method := range order {
+			if method == AuthAgent {
+				return order
+			}
+		}
+		return append([]string{AuthAgent}, order...)
+	}
+	return order
+}
+
+// keyBytes 返回私钥内容，内联私钥优先于私钥文件
+func (d *SSHConfigData) keyBytes() ([]byte, error) {
+	key, err := d.getKey()
+	if err != nil {
+		return nil, err
+	}
+	if key != "" {
+		return []byte(key), nil
+	}
+	if d.KeyPath == "" {
+		return nil, nil
+	}
+	return os.ReadFile(expandHome(d.KeyPath))
+}
+
+// expandHome 展开路径开头的 ~
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[1:])
+	}
+	return path
+}
+
+// parsePrivateKey 解析私钥，私钥加密时先使用已保存的口令，没有则通过 prompt 询问
+func parsePrivateKey(pemBytes []byte, passphrase string, prompt func() (string, bool)) (ssh.Signer, error) {
+	signer, err := ssh.ParsePrivateKey(pemBytes)
+	if err == nil {
+		return signer, nil
+	}
+	var missing *ssh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		return nil, err
+	}
+	if passphrase == "" {
+		if prompt == nil {
+			return nil, err
+		}
+		var ok bool
+		passphrase, ok = prompt()
+		if !ok {
+			return nil, errors.New("passphrase input canceled")
+		}
+	}
+	return ssh.ParsePrivateKeyWithPassphrase(pemBytes, []byte(passphrase))
+}
+
+// openAgent 通过 SSH_AUTH_SOCK 连接 ssh-agent
+func openAgent() (agent.ExtendedAgent, io.Closer, error) {
+	sock := os.Getenv("SSH_AUTH_SOCK")
+	if sock == "" {
+		return nil, nil, errors.New("SSH_AUTH_SOCK is not set")
+	}
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect ssh-agent: %w", err)
+	}
+	return agent.NewClient(conn), conn, nil
+}
+
+// agentAuthMethod 使用 agent 中的密钥认证
+func agentAuthMethod(ag agent.Agent) ssh.AuthMethod {
+	return ssh.PublicKeysCallback(ag.Signers)
+}
+
+// publicKeysFrom 按顺序合并多个来源的密钥，获取失败的来源记录日志后跳过
+// x/crypto 不会再次尝试已失败的同名认证方式，agent 和私钥必须放在同一个 publickey 认证中
+func publicKeysFrom(sources []func() ([]ssh.Signer, error)) ssh.AuthMethod {
+	return ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		all := make([]ssh.Signer, 0)
+		for _, source := range sources {
+			signers, err := source()
+			if err != nil {
+				log.Printf("Failed to load keys: %v", err)
+				continue
+			}
+			all = append(all, signers...)
+		}
+		return all, nil
+	})
+}
+
+// forwardAgent 将 agent 转发到远程会话
+func forwardAgent(client *ssh.Client, session *ssh.Session, ag agent.Agent) error {
+	if err := agent.ForwardToAgent(client, ag); err != nil {
+		return err
+	}
+	return agent.RequestAgentForwarding(session)
+}
+
+// challengePrompt 显示服务端的交互式认证问题并返回用户的回答，echos 为 false 的问题需要隐藏输入
+type challengePrompt func(name, instruction string, questions []string, echos []bool) ([]string, bool)
+
+// keyboardInteractive 处理 keyboard-interactive 认证
+// 已保存的密码自动回答第一个问题，其余问题（例如 OTP 验证码）交给 prompt
+func keyboardInteractive(password string, prompt challengePrompt) ssh.KeyboardInteractiveChallenge {
+	usePassword := password != ""
+	return func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+		if len(questions) == 0 {
+			return []string{}, nil
+		}
+		answers := make([]string, 0, len(questions))
+		if usePassword {
+			usePassword = false
+			answers = append(answers, password)
+			questions, echos = questions[1:], echos[1:]
+			if len(questions) == 0 {
+				return answers, nil
+			}
+		}
+		replies, ok := prompt(name, instruction, questions, echos)
+		if !ok {
+			return nil, errors.New("keyboard-interactive authentication canceled")
+		}
+		if len(replies) != len(questions) {
+			return nil, fmt.Errorf("expected %d answers, got %d", len(questions), len(replies))
+		}
+		return append(answers, replies...), nil
+	}
+}
+
+// keySigners 加载配置的私钥，配置了证书时证书在前，未配置私钥时返回空
+func (c *SSHConfig) keySigners(win *Window) ([]ssh.Signer, error) {
+	conf := c.data
+	pemBytes, err := conf.keyBytes()
+	if err != nil {
+		return nil, err
+	}
+	if len(pemBytes) == 0 {
+		return nil, nil
+	}
+	passphrase, err := conf.getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	signer, err := parsePrivateKey(pemBytes, passphrase, func() (string, bool) {
+		return win.promptSecret("Private Key Passphrase", "Passphrase for "+conf.Name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if conf.CertPath == "" {
+		return []ssh.Signer{signer}, nil
+	}
+	// 先尝试证书，服务端不信任证书时再使用私钥本身，证书无法使用时只使用私钥
+	cert, err := loadCertificate(conf.CertPath, ssh.UserCert)
+	if err != nil {
+		log.Printf("%s: %v", conf.CertPath, err)
+		return []ssh.Signer{signer}, nil
+	}
+	if err := checkCertValidity(cert, time.Now()); err != nil {
+		log.Printf("%s: %v", conf.CertPath, err)
+	}
+	withCert, err := certSigner(signer, cert)
+	if err != nil {
+		log.Printf("%s: %v", conf.CertPath, err)
+		return []ssh.Signer{signer}, nil
+	}
+	return []ssh.Signer{withCert, signer}, nil
+}
+
+// authMethods 按配置的顺序构建认证方式，ag 为 nil 时跳过 agent 认证
+func (c *SSHConfig) authMethods(win *Window, ag agent.Agent) ([]ssh.AuthMethod, error) {
+	conf := c.data
+	methods := make([]ssh.AuthMethod, 0)
+	// 第一次出现 key 或 agent 的位置放置合并后的 publickey 认证
+	publicKeyIndex := -1
+	var keySources []func() ([]ssh.Signer, error)
+	addKeys := func(source func() ([]ssh.Signer, error)) {
+		if publicKeyIndex < 0 {
+			publicKeyIndex = len(methods)
+			methods = append(methods, nil)
+		}
+		keySources = append(keySources, source)
+	}
+	for _, method := range conf.authOrder() {
+		switch method {
+		case AuthKey:
+			// 私钥无法读取、解析或取消输入口令时跳过，继续尝试其他认证方式
+			signers, err := c.keySigners(win)
+			if err != nil {
+				log.Printf("Skip key auth for %s: %v", conf.Name, err)
+				continue
+			}
+			if len(signers) == 0 {
+				continue
+			}
+			addKeys(func() ([]ssh.Signer, error) {
+				return signers, nil
+			})
+		case AuthPassword:
+			password, err := conf.getPassword()
+			if err != nil {
+				return nil, err
+			}
+			methods = append(methods, ssh.Password(password))
+		case AuthKeyboardInteractive:
+			password, err := conf.getPassword()
+			if err != nil {
+				return nil, err
+			}
+			methods = append(methods, ssh.KeyboardInteractive(keyboardInteractive(password, win.promptChallenge)))
+		case AuthAgent:
+			if ag != nil {
+				addKeys(ag.Signers)
+			}
+		}
+	}
+	if publicKeyIndex >= 0 {
+		methods[publicKeyIndex] = publicKeysFrom(keySources)
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("no available auth method")
+	}
+	return methods, nil
+}
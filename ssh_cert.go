@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// loadCertificate 读取 OpenSSH 证书文件，例如 id_ed25519-cert.pub
+func loadCertificate(path string, certType uint32) (*ssh.Certificate, error) {
+	data, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return nil, err
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate %s: %w", path, err)
+	}
+	cert, ok := key.(*ssh.Certificate)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ssh certificate", path)
+	}
+	if cert.CertType != certType {
+		return nil, fmt.Errorf("%s is a %s certificate", path, certTypeName(cert.CertType))
+	}
+	return cert, nil
+}
+
+// certSigner 使用证书包装私钥签名器，证书必须与私钥匹配
+func certSigner(signer ssh.Signer, cert *ssh.Certificate) (ssh.Signer, error) {
+	certSigner, err := ssh.NewCertSigner(cert, signer)
+	if err != nil {
+		return nil, fmt.Errorf("certificate does not match private key: %w", err)
+	}
+	return certSigner, nil
+}
+
+// checkCertValidity 检查证书是否在有效期内
+func checkCertValidity(cert *ssh.Certificate, now time.Time) error {
+	unix := uint64(now.Unix())
+	if unix < cert.ValidAfter {
+		return errors.New("certificate is not yet valid")
+	}
+	if cert.ValidBefore != ssh.CertTimeInfinity && unix >= cert.ValidBefore {
+		return errors.New("certificate has expired")
+	}
+	return nil
+}
+
+func certTypeName(certType uint32) string {
+	switch certType {
+	case ssh.UserCert:
+		return "user"
+	case ssh.HostCert:
+		return "host"
+	}
+	return "unknown"
+}
+
+// formatCertTime 格式化证书时间，无限期时返回 forever
+func formatCertTime(t uint64) string {
+	if t == ssh.CertTimeInfinity {
+		return "forever"
+	}
+	return time.Unix(int64(t), 0).Format("2006-01-02 15:04:05")
+}
+
+// formatCertValidity 返回证书有效期，已过期或尚未生效时附带说明
+func formatCertValidity(cert *ssh.Certificate, now time.Time) string {
+	validity := fmt.Sprintf("%s to %s", formatCertTime(cert.ValidAfter), formatCertTime(cert.ValidBefore))
+	if err := checkCertValidity(cert, now); err != nil {
+		validity += " (" + err.Error() + ")"
+	}
+	return validity
+}
+
+// certInfo 返回证书的展示信息，prefix 用于区分用户证书和主机证书
+func certInfo(prefix string, cert *ssh.Certificate) []InfoItem {
+	principals := "any"
+	if len(cert.ValidPrincipals) > 0 {
+		principals = strings.Join(cert.ValidPrincipals, ", ")
+	}
+	return []InfoItem{
+		{Name: prefix + " Key ID", Value: cert.KeyId},
+		{Name: prefix + " Principals", Value: principals},
+		{Name: prefix + " Valid", Value: formatCertValidity(cert, time.Now())},
+		{Name: prefix + " CA", Value: ssh.FingerprintSHA256(cert.SignatureKey)},
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// newTestCert 使用 CA 签发证书
+func newTestCert(t *testing.T, ca ssh.Signer, key ssh.PublicKey, certType uint32, principals []string, validFor time.Duration) *ssh.Certificate {
+	t.Helper()
+	now := time.Now()
+	cert := &ssh.Certificate{
+		Key:             key,
+		KeyId:           "test-cert",
+		CertType:        certType,
+		ValidPrincipals: principals,
+		ValidAfter:      uint64(now.Add(-time.Minute).Unix()),
+		ValidBefore:     uint64(now.Add(validFor).Unix()),
+	}
+	if err := cert.SignCert(rand.Reader, ca); err != nil {
+		t.Fatalf("SignCert failed: %v", err)
+	}
+	return cert
+}
+
+func newTestSigner(t *testing.T) ssh.Signer {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	signer, err := ssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatalf("NewSignerFromKey failed: %v", err)
+	}
+	return signer
+}
+
+// TestUserCertAuth 测试使用 CA 签发的用户证书认证
+func TestUserCertAuth(t *testing.T) {
+	ca := newTestSigner(t)
+	userKey := newTestSigner(t)
+	checker := &ssh.CertChecker{
+		IsUserAuthority: func(auth ssh.PublicKey) bool {
+			return bytes.Equal(auth.Marshal(), ca.PublicKey().Marshal())
+		},
+	}
+	addr := newTestSSHServer(t, &ssh.ServerConfig{PublicKeyCallback: checker.Authenticate})
+
+	// 没有证书时私钥不被信任
+	if client, err := dialTestSSHServer(addr, ssh.PublicKeys(userKey)); err == nil {
+		client.Close()
+		t.Fatal("Auth without certificate should fail")
+	}
+
+	cert := newTestCert(t, ca, userKey.PublicKey(), ssh.UserCert, []string{"test"}, time.Hour)
+	path := filepath.Join(t.TempDir(), "id_ed25519-cert.pub")
+	if err := os.WriteFile(path, ssh.MarshalAuthorizedKey(cert), 0600); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := loadCertificate(path, ssh.UserCert)
+	if err != nil {
+		t.Fatalf("loadCertificate failed: %v", err)
+	}
+	if _, err := loadCertificate(path, ssh.HostCert); err == nil {
+		t.Error("User certificate should not be loaded as host certificate")
+	}
+	signer, err := certSigner(userKey, loaded)
+	if err != nil {
+		t.Fatalf("certSigner failed: %v", err)
+	}
+	client, err := dialTestSSHServer(addr, ssh.PublicKeys(signer, userKey))
+	if err != nil {
+		t.Fatalf("Auth with certificate failed: %v", err)
+	}
+	client.Close()
+
+	if _, err := certSigner(newTestSigner(t), loaded); err == nil {
+		t.Error("Certificate should not match another private key")
+	}
+}
+
+func TestCheckCertValidity(t *testing.T) {
+	ca := newTestSigner(t)
+	cert := newTestCert(t, ca, newTestSigner(t).PublicKey(), ssh.UserCert, nil, time.Hour)
+	if err := checkCertValidity(cert, time.Now()); err != nil {
+		t.Errorf("Certificate should be valid: %v", err)
+	}
+	if err := checkCertValidity(cert, time.Now().Add(2*time.Hour)); err == nil {
+		t.Error("Certificate should be expired")
+	}
+	if err := checkCertValidity(cert, time.Now().Add(-time.Hour)); err == nil {
+		t.Error("Certificate should not be valid yet")
+	}
+	cert.ValidBefore = ssh.CertTimeInfinity
+	if err := checkCertValidity(cert, time.Now().Add(24*365*time.Hour)); err != nil {
+		t.Errorf("Certificate without expiry should be valid: %v", err)
+	}
+}
+
+// TestHostCertAuthority 测试 known_hosts 中 @cert-authority 签发的主机证书
+func TestHostCertAuthority(t *testing.T) {
+	ca := newTestSigner(t)
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	line := "@cert-authority " + knownhosts.Line([]string{"*.example.com"}, ca.PublicKey()) + "\n"
+	if err := os.WriteFile(path, []byte(line), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var prompted ssh.PublicKey
+	callback, err := newHostKeyCallback(path, func(hostname string, key ssh.PublicKey, changed bool) hostKeyDecision {
+		prompted = key
+		return hostKeyReject
+	})
+	if err != nil {
+		t.Fatalf("newHostKeyCallback failed: %v", err)
+	}
+
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 22}
+	hostKey := newTestSigner(t).PublicKey()
+	cert := newTestCert(t, ca, hostKey, ssh.HostCert, []string{"web.example.com"}, time.Hour)
+	if err := callback("web.example.com:22", remote, cert); err != nil {
+		t.Errorf("Host certificate signed by trusted CA should pass: %v", err)
+	}
+	if prompted != nil {
+		t.Error("Trusted host certificate should not prompt")
+	}
+
+	// 不受信任的 CA 签发的证书按普通主机密钥询问用户
+	other := newTestCert(t, newTestSigner(t), hostKey, ssh.HostCert, []string{"web.example.com"}, time.Hour)
+	if err := callback("web.example.com:22", remote, other); err == nil {
+		t.Error("Untrusted host certificate should be rejected")
+	}
+	if prompted == nil || !bytes.Equal(prompted.Marshal(), hostKey.Marshal()) {
+		t.Error("Untrusted host certificate should prompt for the plain host key")
+	}
+}
@@ -0,0 +1,485 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+)
+
+// Include 嵌套的最大深度，与 OpenSSH 保持一致
+const sshConfigMaxDepth = 16
+
+// sshConfigBlock ssh_config 中的一个 Host 段
+type sshConfigBlock struct {
+	patterns      []string
+	options       map[string]string // 小写的选项名，同一段内以第一次出现的值为准
+	identityFiles []string
+}
+
+// sshConfigFile 解析后的 ssh_config，包含 Include 引入的内容
+type sshConfigFile struct {
+	blocks []*sshConfigBlock
+}
+
+// sshConfigHost 按 ssh_config 规则解析出的主机配置
+type sshConfigHost struct {
+	Alias         string
+	HostName      string
+	User          string
+	Port          int
+	IdentityFiles []string
+	ProxyJump     string
+}
+
+// sshConfigPath 返回用户的 ssh_config 路径
+func sshConfigPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssh", "config")
+}
+
+// loadSSHConfig 读取并解析 ssh_config 文件
+func loadSSHConfig(path string) (*sshConfigFile, error) {
+	f := &sshConfigFile{}
+	// 第一个 Host 之前的选项对所有主机生效
+	current := newSSHConfigBlock([]string{"*"})
+	f.blocks = append(f.blocks, current)
+	if _, err := f.parseFile(path, current, 0); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func newSSHConfigBlock(patterns []string) *sshConfigBlock {
+	return &sshConfigBlock{patterns: patterns, options: make(map[string]string)}
+}
+
+func (f *sshConfigFile) parseFile(path string, current *sshConfigBlock, depth int) (*sshConfigBlock, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return f.parse(file, current, depth)
+}
+
+// parse 解析配置内容，返回解析结束时所在的 Host 段
+// Include 的内容按原位置展开，被引入文件中的 Host 段会改变之后选项所属的段
+func (f *sshConfigFile) parse(r io.Reader, current *sshConfigBlock, depth int) (*sshConfigBlock, error) {
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		key, args, err := splitSSHConfigLine(scanner.Text())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if key == "" {
+			continue
+		}
+		switch key {
+		case "host":
+			current = newSSHConfigBlock(args)
+			f.blocks = append(f.blocks, current)
+		case "match":
+			// 不支持 Match 条件，之后的选项不应用到任何主机
+			current = newSSHConfigBlock(nil)
+			f.blocks = append(f.blocks, current)
+		case "include":
+			if depth >= sshConfigMaxDepth {
+				return nil, fmt.Errorf("line %d: include nested too deeply", lineNo)
+			}
+			for _, pattern := range args {
+				pattern = expandHome(pattern)
+				if !filepath.IsAbs(pattern) {
+					pattern = filepath.Join(os.Getenv("HOME"), ".ssh", pattern)
+				}
+				matches, err := filepath.Glob(pattern)
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", lineNo, err)
+				}
+				sort.Strings(matches)
+				for _, match := range matches {
+					if current, err = f.parseFile(match, current, depth+1); err != nil {
+						return nil, fmt.Errorf("%s: %w", match, err)
+					}
+				}
+			}
+		case "identityfile":
+			current.identityFiles = append(current.identityFiles, args...)
+		default:
+			if _, ok := current.options[key]; !ok && len(args) > 0 {
+				current.options[key] = strings.Join(args, " ")
+			}
+		}
+	}
+	return current, scanner.Err()
+}
+
+// splitSSHConfigLine 拆分一行配置，返回小写的选项名和参数，支持 key=value 和双引号
+func splitSSHConfigLine(line string) (string, []string, error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", nil, nil
+	}
+	end := strings.IndexAny(line, " \t=")
+	if end < 0 {
+		return strings.ToLower(line), nil, nil
+	}
+	key := strings.ToLower(line[:end])
+	rest := strings.TrimLeft(line[end:], " \t")
+	rest = strings.TrimLeft(strings.TrimPrefix(rest, "="), " \t")
+
+	args := make([]string, 0)
+	var arg strings.Builder
+	inQuote, hasArg := false, false
+	for _, r := range rest {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+			hasArg = true
+		case (r == ' ' || r == '\t') && !inQuote:
+			if hasArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				hasArg = false
+			}
+		case r == '#' && !inQuote && !hasArg:
+			// 行尾注释
+			return key, args, nil
+		default:
+			arg.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if inQuote {
+		return "", nil, fmt.Errorf("unterminated quote in %q", line)
+	}
+	if hasArg {
+		args = append(args, arg.String())
+	}
+	return key, args, nil
+}
+
+// matchSSHHost 判断主机名是否匹配 Host 模式列表，任一否定模式匹配时整体不匹配
+func matchSSHHost(patterns []string, host string) bool {
+	matched := false
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			if matchWildcard(pattern[1:], host) {
+				return false
+			}
+		} else if matchWildcard(pattern, host) {
+			matched = true
+		}
+	}
+	return matched
+}
+
+// matchWildcard 匹配 ssh_config 的通配符，* 匹配任意字符串，? 匹配单个字符
+func matchWildcard(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if matchWildcard(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+		default:
+			if len(s) == 0 || !strings.EqualFold(pattern[:1], s[:1]) {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return len(s) == 0
+}
+
+// isConcreteHost 判断 Host 模式是否为具体的主机别名
+func isConcreteHost(pattern string) bool {
+	return !strings.ContainsAny(pattern, "*?!")
+}
+
+// Aliases 返回配置中所有具体的主机别名，保持首次出现的顺序
+func (f *sshConfigFile) Aliases() []string {
+	seen := make(map[string]bool)
+	aliases := make([]string, 0)
+	for _, block := range f.blocks {
+		for _, pattern := range block.patterns {
+			if isConcreteHost(pattern) && !seen[pattern] {
+				seen[pattern] = true
+				aliases = append(aliases, pattern)
+			}
+		}
+	}
+	return aliases
+}
+
+// Lookup 按 OpenSSH 的规则解析主机配置，每个选项以第一个匹配段中的值为准
+func (f *sshConfigFile) Lookup(alias string) (*sshConfigHost, error) {
+	options := make(map[string]string)
+	host := &sshConfigHost{Alias: alias, Port: 22}
+	for _, block := range f.blocks {
+		if !matchSSHHost(block.patterns, alias) {
+			continue
+		}
+		for key, value := range block.options {
+			if _, ok := options[key]; !ok {
+				options[key] = value
+			}
+		}
+		host.IdentityFiles = append(host.IdentityFiles, block.identityFiles...)
+	}
+
+	host.HostName = alias
+	if v, ok := options["hostname"]; ok {
+		host.HostName = expandSSHTokens(v, alias, "", alias)
+	}
+	if v, ok := options["user"]; ok {
+		host.User = v
+	}
+	if v, ok := options["port"]; ok {
+		port, err := strconv.Atoi(v)
+		if err != nil || checkPort(v) != nil {
+			return nil, fmt.Errorf("invalid port for %s: %s", alias, v)
+		}
+		host.Port = port
+	}
+	if v, ok := options["proxyjump"]; ok && !strings.EqualFold(v, "none") {
+		host.ProxyJump = v
+	}
+	for i, path := range host.IdentityFiles {
+		host.IdentityFiles[i] = expandSSHTokens(path, host.HostName, host.User, alias)
+	}
+	return host, nil
+}
+
+// expandSSHTokens 展开常用的 % 占位符：%% %h %r %n %d %u
+func expandSSHTokens(s, hostname, remoteUser, alias string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+	localUser := ""
+	if u, err := user.Current(); err == nil {
+		localUser = u.Username
+	}
+	return strings.NewReplacer(
+		"%%", "%",
+		"%h", hostname,
+		"%r", remoteUser,
+		"%n", alias,
+		"%d", os.Getenv("HOME"),
+		"%u", localUser,
+	).Replace(s)
+}
+
+// parseJumpSpec 解析 ProxyJump 中的单个跳板机，格式为 [user@]host[:port]
+func parseJumpSpec(spec string) (user, host string, port int, err error) {
+	spec = strings.TrimPrefix(strings.TrimSpace(spec), "ssh://")
+	if i := strings.LastIndex(spec, "@"); i >= 0 {
+		user, spec = spec[:i], spec[i+1:]
+	}
+	host = spec
+	if i := strings.LastIndex(spec, ":"); i >= 0 && !strings.HasSuffix(spec, "]") {
+		if err = checkPort(spec[i+1:]); err != nil {
+			return
+		}
+		host = spec[:i]
+		port, _ = strconv.Atoi(spec[i+1:])
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	if host == "" {
+		err = fmt.Errorf("invalid jump host: %s", spec)
+	}
+	return
+}
+
+// identityFile 返回第一个存在的私钥文件，与 ssh 一样跳过不存在的文件，都不存在时返回第一个
+func (h *sshConfigHost) identityFile() string {
+	for _, path := range h.IdentityFiles {
+		if _, err := os.Stat(expandHome(path)); err == nil {
+			return path
+		}
+	}
+	if len(h.IdentityFiles) > 0 {
+		return h.IdentityFiles[0]
+	}
+	return ""
+}
+
+// toSSHConfigs 将主机配置转换为 SSH 会话配置
+// ProxyJump 中引用的别名直接作为跳板机名称，别名自身的配置和其他跳板机生成的配置一并返回
+// 已存在同名配置时由调用方跳过
+func (f *sshConfigFile) toSSHConfigs(host *sshConfigHost) ([]*SSHConfigData, error) {
+	return f.collectSSHConfigs(host, map[string]bool{host.Alias: true})
+}
+
+// collectSSHConfigs 转换主机配置并递归转换引用的跳板机别名，visited 避免循环引用
+func (f *sshConfigFile) collectSSHConfigs(host *sshConfigHost, visited map[string]bool) ([]*SSHConfigData, error) {
+	data := &SSHConfigData{
+		Name: host.Alias,
+		Type: "ssh",
+		Host: host.HostName,
+		Port: host.Port,
+		User: host.User,
+	}
+	data.KeyPath = host.identityFile()
+	configs := []*SSHConfigData{data}
+	if host.ProxyJump == "" {
+		return configs, nil
+	}
+
+	aliases := make(map[string]bool)
+	for _, alias := range f.Aliases() {
+		aliases[alias] = true
+	}
+	for _, spec := range splitList(host.ProxyJump) {
+		user, jumpHost, port, err := parseJumpSpec(spec)
+		if err != nil {
+			return nil, err
+		}
+		if aliases[jumpHost] && user == "" && port == 0 {
+			data.JumpHosts = append(data.JumpHosts, jumpHost)
+			if visited[jumpHost] {
+				continue
+			}
+			visited[jumpHost] = true
+			hop, err := f.Lookup(jumpHost)
+			if err != nil {
+				return nil, err
+			}
+			hopConfigs, err := f.collectSSHConfigs(hop, visited)
+			if err != nil {
+				return nil, err
+			}
+			configs = append(configs, hopConfigs...)
+			continue
+		}
+		// 跳板机不是已定义的别名，或者覆盖了用户和端口时，生成单独的配置
+		hop, err := f.Lookup(jumpHost)
+		if err != nil {
+			return nil, err
+		}
+		hopData := &SSHConfigData{Name: spec, Type: "ssh", Host: hop.HostName, Port: hop.Port, User: hop.User}
+		hopData.KeyPath = hop.identityFile()
+		if user != "" {
+			hopData.User = user
+		}
+		if port != 0 {
+			hopData.Port = port
+		}
+		data.JumpHosts = append(data.JumpHosts, spec)
+		configs = append(configs, hopData)
+	}
+	return configs, nil
+}
+
+// showImportSSHConfigDialog 从 ~/.ssh/config 中选择主机导入为 SSH 配置
+func (w *Window) showImportSSHConfigDialog() {
+	file, err := loadSSHConfig(sshConfigPath())
+	if err != nil {
+		w.showError(fmt.Errorf("failed to load ssh config: %w", err))
+		return
+	}
+	hosts := make([]*sshConfigHost, 0)
+	for _, alias := range file.Aliases() {
+		host, err := file.Lookup(alias)
+		if err != nil {
+			w.showError(err)
+			return
+		}
+		hosts = append(hosts, host)
+	}
+	if len(hosts) == 0 {
+		dialog.ShowInformation("Import SSH Config", "No hosts found in "+sshConfigPath(), w.win)
+		return
+	}
+
+	selected := make(map[int]bool)
+	list := widget.NewList(func() int {
+		return len(hosts)
+	}, func() fyne.CanvasObject {
+		return container.NewHBox(widget.NewCheck("", nil), widget.NewLabel(""))
+	}, func(id widget.ListItemID, object fyne.CanvasObject) {
+		box := object.(*fyne.Container)
+		check := box.Objects[0].(*widget.Check)
+		label := box.Objects[1].(*widget.Label)
+
+		host := hosts[id]
+		check.OnChanged = nil
+		check.SetText(host.Alias)
+		check.SetChecked(selected[id])
+		check.OnChanged = func(b bool) {
+			selected[id] = b
+		}
+		target := host.HostName
+		if host.User != "" {
+			target = host.User + "@" + target
+		}
+		if host.Port != 22 {
+			target += ":" + strconv.Itoa(host.Port)
+		}
+		if host.ProxyJump != "" {
+			target += " via " + host.ProxyJump
+		}
+		label.SetText(target)
+		// 已存在同名配置时不能导入
+		if w.findConfig(host.Alias) != nil {
+			check.Disable()
+			label.SetText(target + " (exists)")
+		} else {
+			check.Enable()
+		}
+	})
+	selectAll := widget.NewCheck("Select All", func(b bool) {
+		for i, host := range hosts {
+			selected[i] = b && w.findConfig(host.Alias) == nil
+		}
+		list.Refresh()
+	})
+
+	content := container.NewBorder(selectAll, nil, nil, nil, list)
+	dlg := dialog.NewCustomConfirm("Import SSH Config", "Import", "Cancel", content, func(b bool) {
+		if !b {
+			return
+		}
+		imported := 0
+		for i, host := range hosts {
+			if !selected[i] {
+				continue
+			}
+			configs, err := file.toSSHConfigs(host)
+			if err != nil {
+				w.showError(err)
+				continue
+			}
+			for _, data := range configs {
+				if w.findConfig(data.Name) != nil {
+					continue
+				}
+				w.confs = append(w.confs, &SSHConfig{data: data})
+				imported++
+			}
+		}
+		if imported > 0 {
+			w.save()
+		}
+	}, w.win)
+	dlg.Resize(fyne.NewSize(500, 400))
+	dlg.Show()
+}
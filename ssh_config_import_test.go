@@ -0,0 +1,192 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testSSHConfig = `
+# global options
+User default
+
+Host web-* !web-test
+    User deploy
+    Port 2222
+
+Host web-1 web-2
+    HostName %h.example.com
+    IdentityFile ~/.ssh/web_key
+
+Host db
+    HostName=10.0.0.5
+    User "db admin"
+    ProxyJump bastion
+
+Host app
+    HostName app.internal
+    ProxyJump ops@bastion:2200,web-1
+
+Include conf.d/*.conf
+
+Host *
+    Port 22
+    IdentityFile ~/.ssh/id_ed25519
+`
+
+const testSSHConfigInclude = `
+Host bastion
+    HostName bastion.example.com
+    User jump
+`
+
+func writeTestSSHConfig(t *testing.T) {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".ssh", "conf.d")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bastion.conf"), []byte(testSSHConfigInclude), 0600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(home, ".ssh", "config")
+	if err := os.WriteFile(path, []byte(testSSHConfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSSHConfigAliases(t *testing.T) {
+	writeTestSSHConfig(t)
+	file, err := loadSSHConfig(sshConfigPath())
+	if err != nil {
+		t.Fatalf("loadSSHConfig failed: %v", err)
+	}
+	want := []string{"web-1", "web-2", "db", "app", "bastion"}
+	if got := file.Aliases(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Aliases() = %v, want %v", got, want)
+	}
+}
+
+func TestSSHConfigLookup(t *testing.T) {
+	writeTestSSHConfig(t)
+	file, err := loadSSHConfig(sshConfigPath())
+	if err != nil {
+		t.Fatalf("loadSSHConfig failed: %v", err)
+	}
+	tests := []sshConfigHost{
+		{Alias: "web-1", HostName: "web-1.example.com", User: "default", Port: 2222,
+			IdentityFiles: []string{"~/.ssh/web_key", "~/.ssh/id_ed25519"}},
+		{Alias: "db", HostName: "10.0.0.5", User: "default", Port: 22,
+			IdentityFiles: []string{"~/.ssh/id_ed25519"}, ProxyJump: "bastion"},
+		{Alias: "bastion", HostName: "bastion.example.com", User: "default", Port: 22,
+			IdentityFiles: []string{"~/.ssh/id_ed25519"}},
+		// 否定模式排除 web-test
+		{Alias: "web-test", HostName: "web-test", User: "default", Port: 22,
+			IdentityFiles: []string{"~/.ssh/id_ed25519"}},
+	}
+	for _, want := range tests {
+		got, err := file.Lookup(want.Alias)
+		if err != nil {
+			t.Fatalf("Lookup(%s) failed: %v", want.Alias, err)
+		}
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("Lookup(%s) = %+v, want %+v", want.Alias, *got, want)
+		}
+	}
+}
+
+func TestSplitSSHConfigLine(t *testing.T) {
+	tests := []struct {
+		line string
+		key  string
+		args []string
+	}{
+		{"  # comment", "", nil},
+		{"HostName example.com", "hostname", []string{"example.com"}},
+		{"Port=2222", "port", []string{"2222"}},
+		{"Port = 2222 # ssh", "port", []string{"2222"}},
+		{`IdentityFile "~/my keys/id_rsa"`, "identityfile", []string{"~/my keys/id_rsa"}},
+		{"Host a b\tc", "host", []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		key, args, err := splitSSHConfigLine(tt.line)
+		if err != nil {
+			t.Fatalf("splitSSHConfigLine(%q) failed: %v", tt.line, err)
+		}
+		if key != tt.key || (len(args) > 0 || len(tt.args) > 0) && !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("splitSSHConfigLine(%q) = %q %q, want %q %q", tt.line, key, args, tt.key, tt.args)
+		}
+	}
+	if _, _, err := splitSSHConfigLine(`User "unterminated`); err == nil {
+		t.Error("expected error for unterminated quote")
+	}
+}
+
+func TestSSHConfigProxyJump(t *testing.T) {
+	writeTestSSHConfig(t)
+	file, err := loadSSHConfig(sshConfigPath())
+	if err != nil {
+		t.Fatalf("loadSSHConfig failed: %v", err)
+	}
+
+	host, _ := file.Lookup("db")
+	configs, err := file.toSSHConfigs(host)
+	if err != nil {
+		t.Fatalf("toSSHConfigs failed: %v", err)
+	}
+	if !reflect.DeepEqual(configs[0].JumpHosts, []string{"bastion"}) {
+		t.Errorf("db jump hosts = %v", configs[0].JumpHosts)
+	}
+	// 引用的别名一并导入，否则连接时找不到跳板机配置
+	if len(configs) != 2 || configs[1].Name != "bastion" || configs[1].Host != "bastion.example.com" {
+		t.Fatalf("expected bastion config for db, got %d configs", len(configs))
+	}
+
+	host, _ = file.Lookup("app")
+	configs, err = file.toSSHConfigs(host)
+	if err != nil {
+		t.Fatalf("toSSHConfigs failed: %v", err)
+	}
+	if !reflect.DeepEqual(configs[0].JumpHosts, []string{"ops@bastion:2200", "web-1"}) {
+		t.Errorf("app jump hosts = %v", configs[0].JumpHosts)
+	}
+	names := make([]string, 0, len(configs))
+	for _, c := range configs {
+		names = append(names, c.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"app", "ops@bastion:2200", "web-1"}) {
+		t.Fatalf("expected generated and referenced jump host configs, got %v", names)
+	}
+	hop := configs[1]
+	if hop.Name != "ops@bastion:2200" || hop.Host != "bastion.example.com" || hop.User != "ops" || hop.Port != 2200 {
+		t.Errorf("generated jump host = %+v", hop)
+	}
+}
+
+func TestSSHConfigIdentityFile(t *testing.T) {
+	writeTestSSHConfig(t)
+	file, err := loadSSHConfig(sshConfigPath())
+	if err != nil {
+		t.Fatalf("loadSSHConfig failed: %v", err)
+	}
+	host, _ := file.Lookup("web-1")
+	if path := host.identityFile(); path != "~/.ssh/web_key" {
+		t.Errorf("identity file without existing keys = %q", path)
+	}
+
+	// 跳过不存在的 web_key，使用存在的 id_ed25519
+	keyPath := filepath.Join(os.Getenv("HOME"), ".ssh", "id_ed25519")
+	if err := os.WriteFile(keyPath, []byte("key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	configs, err := file.toSSHConfigs(host)
+	if err != nil {
+		t.Fatalf("toSSHConfigs failed: %v", err)
+	}
+	if configs[0].KeyPath != "~/.ssh/id_ed25519" {
+		t.Errorf("expected existing identity file, got %q", configs[0].KeyPath)
+	}
+}
@@ -0,0 +1,411 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// 端口转发类型，对应 ssh 的 -L、-R、-D 参数
+const (
+	ForwardLocal   = "L"
+	ForwardRemote  = "R"
+	ForwardDynamic = "D"
+)
+
+// ForwardRule 端口转发规则
+type ForwardRule struct {
+	Type   string `json:"type"`             // L / R / D
+	Bind   string `json:"bind"`             // 监听地址，L、D 为本地地址，R 为远程地址
+	Target string `json:"target,omitempty"` // 目标地址，D 不需要
+}
+
+func (r ForwardRule) String() string {
+	if r.Type == ForwardDynamic {
+		return "-D " + r.Bind
+	}
+	return fmt.Sprintf("-%s %s -> %s", r.Type, r.Bind, r.Target)
+}
+
+// parseForwardRule 解析 ssh 风格的转发规则
+// 例如 "L 8080:localhost:80"、"R 0.0.0.0:9000:localhost:3000"、"D 1080"、"L [::1]:8080:[2001:db8::1]:80"
+func parseForwardRule(s string) (ForwardRule, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 {
+		return ForwardRule{}, fmt.Errorf("invalid forward rule: %s", s)
+	}
+	rule := ForwardRule{Type: strings.ToUpper(strings.TrimPrefix(fields[0], "-"))}
+	parts, err := splitForwardSpec(fields[1])
+	if err != nil {
+		return ForwardRule{}, err
+	}
+	switch rule.Type {
+	case ForwardLocal, ForwardRemote:
+		switch len(parts) {
+		case 3:
+			rule.Bind = net.JoinHostPort("localhost", parts[0])
+		case 4:
+			rule.Bind = net.JoinHostPort(parts[0], parts[1])
+			parts = parts[1:]
+		default:
+			return ForwardRule{}, fmt.Errorf("invalid forward spec: %s", fields[1])
+		}
+		rule.Target = net.JoinHostPort(parts[1], parts[2])
+		if err := checkPort(parts[2]); err != nil {
+			return ForwardRule{}, err
+		}
+		if err := checkPort(parts[0]); err != nil {
+			return ForwardRule{}, err
+		}
+	case ForwardDynamic:
+		switch len(parts) {
+		case 1:
+			rule.Bind = net.JoinHostPort("localhost", parts[0])
+		case 2:
+			rule.Bind = net.JoinHostPort(parts[0], parts[1])
+			parts = parts[1:]
+		default:
+			return ForwardRule{}, fmt.Errorf("invalid forward spec: %s", fields[1])
+		}
+		if err := checkPort(parts[0]); err != nil {
+			return ForwardRule{}, err
+		}
+	default:
+		return ForwardRule{}, fmt.Errorf("unknown forward type: %s", fields[0])
+	}
+	return rule, nil
+}
+
+// splitForwardSpec 按冒号拆分转发参数，与 ssh -L 相同，方括号中的 IPv6 地址作为一项并去掉方括号
+func splitForwardSpec(spec string) ([]string, error) {
+	parts := make([]string, 0, 4)
+	rest := spec
+	for {
+		var part string
+		if strings.HasPrefix(rest, "[") {
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid forward spec: %s, missing ]", spec)
+			}
+			part, rest = rest[1:end], rest[end+1:]
+			if rest != "" && !strings.HasPrefix(rest, ":") {
+				return nil, fmt.Errorf("invalid forward spec: %s", spec)
+			}
+		} else if i := strings.Index(rest, ":"); i >= 0 {
+			part, rest = rest[:i], rest[i:]
+		} else {
+			part, rest = rest, ""
+		}
+		parts = append(parts, part)
+		if rest == "" {
+			return parts, nil
+		}
+		rest = rest[1:]
+	}
+}
+
+// parseForwardRules 解析多行转发规则，忽略空行
+func parseForwardRules(s string) ([]ForwardRule, error) {
+	rules := make([]ForwardRule, 0)
+	for _, line := range strings.Split(s, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		rule, err := parseForwardRule(line)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// formatForwardRules 将转发规则格式化为多行文本，与 parseForwardRules 对应
+func formatForwardRules(rules []ForwardRule) string {
+	lines := make([]string, 0, len(rules))
+	for _, rule := range rules {
+		bindHost, bindPort, _ := net.SplitHostPort(rule.Bind)
+		spec := bindPort
+		if bindHost != "localhost" {
+			spec = rule.Bind
+		}
+		if rule.Type != ForwardDynamic {
+			spec += ":" + rule.Target
+		}
+		lines = append(lines, rule.Type+" "+spec)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func checkPort(s string) error {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 0 || port > 65535 {
+		return fmt.Errorf("invalid port: %s", s)
+	}
+	return nil
+}
+
+// Tunnel 一条正在运行的端口转发
+type Tunnel struct {
+	Session string
+	Rule    ForwardRule
+
+	listener net.Listener
+	client   *ssh.Client
+	connLock sync.Mutex
+	active   map[net.Conn]struct{} // 正在转发的本地和目标连接，停止转发时一并关闭
+	handlers sync.WaitGroup
+	conns    atomic.Int32
+	sent     atomic.Int64 // 发往目标的字节数
+	received atomic.Int64 // 从目标收到的字节数
+	closed   atomic.Bool
+}
+
+// Stats 返回当前连接数和收发字节数
+func (t *Tunnel) Stats() (conns int32, sent, received int64) {
+	return t.conns.Load(), t.sent.Load(), t.received.Load()
+}
+
+func (t *Tunnel) Title() string {
+	return fmt.Sprintf("%s  %s", t.Session, t.Rule)
+}
+
+func (t *Tunnel) Status() string {
+	conns, sent, received := t.Stats()
+	return fmt.Sprintf("%d conns  ↑ %s  ↓ %s", conns, formatBytes(sent), formatBytes(received))
+}
+
+// Close 停止监听并关闭所有正在转发的连接
+func (t *Tunnel) Close() error {
+	t.connLock.Lock()
+	t.closed.Store(true)
+	for conn := range t.active {
+		conn.Close()
+	}
+	t.connLock.Unlock()
+	return t.listener.Close()
+}
+
+// track 记录正在转发的连接，转发已停止时返回 false
+func (t *Tunnel) track(conn net.Conn) bool {
+	t.connLock.Lock()
+	defer t.connLock.Unlock()
+	if t.closed.Load() {
+		return false
+	}
+	if t.active == nil {
+		t.active = make(map[net.Conn]struct{})
+	}
+	t.active[conn] = struct{}{}
+	return true
+}
+
+func (t *Tunnel) untrack(conn net.Conn) {
+	t.connLock.Lock()
+	delete(t.active, conn)
+	t.connLock.Unlock()
+}
+
+// serve 接受连接直到监听关闭，返回前等待所有连接结束
+func (t *Tunnel) serve() {
+	defer t.handlers.Wait()
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			if !t.closed.Load() {
+				log.Printf("Tunnel %s stopped: %v", t.Rule, err)
+			}
+			return
+		}
+		t.handlers.Add(1)
+		go func() {
+			defer t.handlers.Done()
+			t.handle(conn)
+		}()
+	}
+}
+
+func (t *Tunnel) handle(conn net.Conn) {
+	defer conn.Close()
+	if !t.track(conn) {
+		return
+	}
+	defer t.untrack(conn)
+	var target net.Conn
+	var err error
+	switch t.Rule.Type {
+	case ForwardLocal:
+		target, err = t.client.Dial("tcp", t.Rule.Target)
+	case ForwardRemote:
+		target, err = net.Dial("tcp", t.Rule.Target)
+	case ForwardDynamic:
+		var addr string
+		addr, err = socks5Handshake(conn)
+		if err == nil {
+			target, err = t.client.Dial("tcp", addr)
+			err = socks5Reply(conn, err)
+		}
+	}
+	if err != nil {
+		log.Printf("Tunnel %s: %v", t.Rule, err)
+		return
+	}
+	defer target.Close()
+	if !t.track(target) {
+		return
+	}
+	defer t.untrack(target)
+
+	t.conns.Add(1)
+	defer t.conns.Add(-1)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		io.Copy(&countingWriter{w: target, n: &t.sent}, conn)
+		closeWrite(target)
+	}()
+	go func() {
+		defer wg.Done()
+		io.Copy(&countingWriter{w: conn, n: &t.received}, target)
+		closeWrite(conn)
+	}()
+	wg.Wait()
+}
+
+// countingWriter 统计写入的字节数，用于实时显示转发流量
+type countingWriter struct {
+	w io.Writer
+	n *atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// closeWrite 半关闭连接，让对端感知 EOF
+func closeWrite(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		cw.CloseWrite()
+	}
+}
+
+// startTunnel 在 SSH 连接上启动一条转发
+func startTunnel(session string, client *ssh.Client, rule ForwardRule) (*Tunnel, error) {
+	var listener net.Listener
+	var err error
+	if rule.Type == ForwardRemote {
+		listener, err = client.Listen("tcp", rule.Bind)
+	} else {
+		listener, err = net.Listen("tcp", rule.Bind)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to start tunnel %s: %w", rule, err)
+	}
+	tunnel := &Tunnel{Session: session, Rule: rule, listener: listener, client: client}
+	go tunnel.serve()
+	return tunnel, nil
+}
+
+// SOCKS5 协议常量，见 RFC 1928
+const (
+	socks5Version         = 0x05
+	socks5NoAuth          = 0x00
+	socks5NoAcceptable    = 0xff
+	socks5CmdConnect      = 0x01
+	socks5AtypIPv4        = 0x01
+	socks5AtypDomain      = 0x03
+	socks5AtypIPv6        = 0x04
+	socks5Succeeded       = 0x00
+	socks5Failure         = 0x01
+	socks5CmdNotSupported = 0x07
+)
+
+// socks5Handshake 处理 SOCKS5 握手，只支持无认证的 CONNECT 请求，返回目标地址
+func socks5Handshake(conn net.Conn) (string, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(conn, header); err != nil {
+		return "", err
+	}
+	if header[0] != socks5Version {
+		return "", fmt.Errorf("unsupported socks version: %d", header[0])
+	}
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(conn, methods); err != nil {
+		return "", err
+	}
+	method := byte(socks5NoAcceptable)
+	for _, m := range methods {
+		if m == socks5NoAuth {
+			method = socks5NoAuth
+		}
+	}
+	if _, err := conn.Write([]byte{socks5Version, method}); err != nil {
+		return "", err
+	}
+	if method == socks5NoAcceptable {
+		return "", errors.New("no acceptable socks auth method")
+	}
+
+	request := make([]byte, 4)
+	if _, err := io.ReadFull(conn, request); err != nil {
+		return "", err
+	}
+	if request[1] != socks5CmdConnect {
+		conn.Write([]byte{socks5Version, socks5CmdNotSupported, 0, socks5AtypIPv4, 0, 0, 0, 0, 0, 0})
+		return "", fmt.Errorf("unsupported socks command: %d", request[1])
+	}
+	var host string
+	switch request[3] {
+	case socks5AtypIPv4, socks5AtypIPv6:
+		size := net.IPv4len
+		if request[3] == socks5AtypIPv6 {
+			size = net.IPv6len
+		}
+		ip := make([]byte, size)
+		if _, err := io.ReadFull(conn, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case socks5AtypDomain:
+		size := make([]byte, 1)
+		if _, err := io.ReadFull(conn, size); err != nil {
+			return "", err
+		}
+		domain := make([]byte, size[0])
+		if _, err := io.ReadFull(conn, domain); err != nil {
+			return "", err
+		}
+		host = string(domain)
+	default:
+		return "", fmt.Errorf("unsupported socks address type: %d", request[3])
+	}
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(conn, port); err != nil {
+		return "", err
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// socks5Reply 返回 CONNECT 结果，连接失败时返回原错误
+func socks5Reply(conn net.Conn, dialErr error) error {
+	status := byte(socks5Succeeded)
+	if dialErr != nil {
+		status = socks5Failure
+	}
+	if _, err := conn.Write([]byte{socks5Version, status, 0, socks5AtypIPv4, 0, 0, 0, 0, 0, 0}); err != nil && dialErr == nil {
+		return err
+	}
+	return dialErr
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// TestParseForwardRule 测试转发规则解析
+func TestParseForwardRule(t *testing.T) {
+	testCases := []struct {
+		spec string
+		want ForwardRule
+	}{
+		{"L 8080:localhost:80", ForwardRule{Type: ForwardLocal, Bind: "localhost:8080", Target: "localhost:80"}},
+		{"-L 0.0.0.0:8080:db:5432", ForwardRule{Type: ForwardLocal, Bind: "0.0.0.0:8080", Target: "db:5432"}},
+		{"R 9000:localhost:3000", ForwardRule{Type: ForwardRemote, Bind: "localhost:9000", Target: "localhost:3000"}},
+		{"d 1080", ForwardRule{Type: ForwardDynamic, Bind: "localhost:1080"}},
+		{"D 127.0.0.1:1080", ForwardRule{Type: ForwardDynamic, Bind: "127.0.0.1:1080"}},
+		{"L [::1]:8080:localhost:80", ForwardRule{Type: ForwardLocal, Bind: "[::1]:8080", Target: "localhost:80"}},
+		{"L 8080:[2001:db8::1]:80", ForwardRule{Type: ForwardLocal, Bind: "localhost:8080", Target: "[2001:db8::1]:80"}},
+		{"R [::]:9000:[::1]:3000", ForwardRule{Type: ForwardRemote, Bind: "[::]:9000", Target: "[::1]:3000"}},
+		{"D [::1]:1080", ForwardRule{Type: ForwardDynamic, Bind: "[::1]:1080"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.spec, func(t *testing.T) {
+			rule, err := parseForwardRule(tc.spec)
+			if err != nil {
+				t.Fatalf("parseForwardRule failed: %v", err)
+			}
+			if rule != tc.want {
+				t.Errorf("got=%+v, want=%+v", rule, tc.want)
+			}
+		})
+	}
+
+	invalid := []string{"", "L", "L 8080", "L abc:localhost:80", "X 8080:localhost:80", "D 1:2:3", "L [::1:8080:localhost:80", "L [::1]x:8080:localhost:80"}
+	for _, spec := range invalid {
+		if _, err := parseForwardRule(spec); err == nil {
+			t.Errorf("parseForwardRule(%q) should fail", spec)
+		}
+	}
+}
+
+// TestFormatForwardRules 测试转发规则格式化后可以重新解析
+func TestFormatForwardRules(t *testing.T) {
+	rules, err := parseForwardRules("L 8080:localhost:80\n\nR 0.0.0.0:9000:localhost:3000\nD 1080\nL [::1]:8080:[2001:db8::1]:80\n")
+	if err != nil {
+		t.Fatalf("parseForwardRules failed: %v", err)
+	}
+	if len(rules) != 4 {
+		t.Fatalf("Expected 4 rules, got %d", len(rules))
+	}
+	parsed, err := parseForwardRules(formatForwardRules(rules))
+	if err != nil {
+		t.Fatalf("parseForwardRules of formatted rules failed: %v", err)
+	}
+	for i := range rules {
+		if parsed[i] != rules[i] {
+			t.Errorf("Rule %d changed after format: got=%+v, want=%+v", i, parsed[i], rules[i])
+		}
+	}
+}
+
+// newEchoServer 启动回显服务，返回监听地址
+func newEchoServer(t *testing.T) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// newForwardTestClient 连接允许任意密码的测试 SSH 服务
+func newForwardTestClient(t *testing.T) *ssh.Client {
+	t.Helper()
+	addr := newTestSSHServer(t, &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			return nil, nil
+		},
+	})
+	client, err := dialTestSSHServer(addr, ssh.Password("test"))
+	if err != nil {
+		t.Fatalf("dialTestSSHServer failed: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// assertEcho 通过连接发送数据并校验回显
+func assertEcho(t *testing.T, conn net.Conn, msg string) {
+	t.Helper()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Write([]byte(msg + "\n")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		t.Fatalf("Read failed: %v", err)
+	}
+	if line != msg+"\n" {
+		t.Errorf("Expected echo %q, got %q", msg, line)
+	}
+}
+
+// TestLocalForward 测试本地端口转发和流量统计
+func TestLocalForward(t *testing.T) {
+	client := newForwardTestClient(t)
+	echo := newEchoServer(t)
+
+	tunnel, err := startTunnel("test", client, ForwardRule{Type: ForwardLocal, Bind: "127.0.0.1:0", Target: echo})
+	if err != nil {
+		t.Fatalf("startTunnel failed: %v", err)
+	}
+	defer tunnel.Close()
+
+	conn, err := net.Dial("tcp", tunnel.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial tunnel failed: %v", err)
+	}
+	assertEcho(t, conn, "hello")
+	conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conns, sent, received := tunnel.Stats()
+		if conns == 0 && sent == 6 && received == 6 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Unexpected tunnel stats: conns=%d sent=%d received=%d", conns, sent, received)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestTunnelCloseStopsConnections 测试停止转发时关闭正在转发的连接
+func TestTunnelCloseStopsConnections(t *testing.T) {
+	client := newForwardTestClient(t)
+	echo := newEchoServer(t)
+
+	tunnel, err := startTunnel("test", client, ForwardRule{Type: ForwardLocal, Bind: "127.0.0.1:0", Target: echo})
+	if err != nil {
+		t.Fatalf("startTunnel failed: %v", err)
+	}
+	conn, err := net.Dial("tcp", tunnel.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial tunnel failed: %v", err)
+	}
+	defer conn.Close()
+	assertEcho(t, conn, "hello")
+
+	tunnel.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Expected EOF after tunnel closed, got %v", err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if conns, _, _ := tunnel.Stats(); conns == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Connection handlers should exit after tunnel closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestDynamicForward 测试 SOCKS5 动态转发
+func TestDynamicForward(t *testing.T) {
+	client := newForwardTestClient(t)
+	echo := newEchoServer(t)
+	host, portStr, _ := net.SplitHostPort(echo)
+	port, _ := net.LookupPort("tcp", portStr)
+
+	tunnel, err := startTunnel("test", client, ForwardRule{Type: ForwardDynamic, Bind: "127.0.0.1:0"})
+	if err != nil {
+		t.Fatalf("startTunnel failed: %v", err)
+	}
+	defer tunnel.Close()
+
+	conn, err := net.Dial("tcp", tunnel.listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial tunnel failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	// 握手：无认证
+	conn.Write([]byte{socks5Version, 1, socks5NoAuth})
+	reply := make([]byte, 2)
+	if _, err := io.ReadFull(conn, reply); err != nil || reply[1] != socks5NoAuth {
+		t.Fatalf("Unexpected method reply: %v %v", reply, err)
+	}
+
+	// CONNECT 请求，使用域名地址
+	request := []byte{socks5Version, socks5CmdConnect, 0, socks5AtypDomain, byte(len(host))}
+	request = append(request, host...)
+	request = binary.BigEndian.AppendUint16(request, uint16(port))
+	conn.Write(request)
+	reply = make([]byte, 10)
+	if _, err := io.ReadFull(conn, reply); err != nil || reply[1] != socks5Succeeded {
+		t.Fatalf("Unexpected connect reply: %v %v", reply, err)
+	}
+
+	assertEcho(t, conn, "socks")
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// hostKeyDecision 用户对未知或已变更主机密钥的处理方式
+type hostKeyDecision int
+
+const (
+	hostKeyReject hostKeyDecision = iota
+	hostKeyAcceptOnce
+	hostKeyAcceptSave
+)
+
+// hostKeyPrompt 询问用户是否信任主机密钥，changed 表示与 known_hosts 中记录的密钥不一致
+type hostKeyPrompt func(hostname string, key ssh.PublicKey, changed bool) hostKeyDecision
+
+// knownHostsPath 返回 known_hosts 文件路径
+func knownHostsPath() string {
+	return filepath.Join(os.Getenv("HOME"), ".ssh", "known_hosts")
+}
+
+// ensureKnownHosts 确保 known_hosts 文件存在
+func ensureKnownHosts(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create known_hosts directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create known_hosts: %w", err)
+	}
+	return f.Close()
+}
+
+// appendKnownHost 将主机密钥追加到 known_hosts
+func appendKnownHost(path, hostname string, key ssh.PublicKey) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteString(knownhosts.Line([]string{knownhosts.Normalize(hostname)}, key) + "\n")
+	return err
+}
+
+// newHostKeyCallback 基于 known_hosts 校验主机密钥，未知或变更的密钥交给 prompt 决定
+func newHostKeyCallback(path string, prompt hostKeyPrompt) (ssh.HostKeyCallback, error) {
+	if err := ensureKnownHosts(path); err != nil {
+		return nil, err
+	}
+	check, err := knownhosts.New(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create host key callback: %w", err)
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		err := check(hostname, remote, key)
+		if cert, ok := key.(*ssh.Certificate); ok {
+			// 主机证书由 known_hosts 中的 @cert-authority 签发时直接信任
+			if err == nil {
+				return nil
+			}
+			// 否则与 OpenSSH 一样按普通主机密钥校验证书中的公钥
+			log.Printf("Host certificate for %s not trusted: %v", hostname, err)
+			key = cert.Key
+			err = check(hostname, remote, key)
+		}
+		var keyErr *knownhosts.KeyError
+		if err == nil || !errors.As(err, &keyErr) {
+			return err
+		}
+		changed := hostKeyChanged(keyErr.Want, key)
+		switch prompt(hostname, key, changed) {
+		case hostKeyAcceptOnce:
+			return nil
+		case hostKeyAcceptSave:
+			if changed {
+				// 已变更的密钥不自动写入，避免 known_hosts 中同一主机出现冲突记录
+				return nil
+			}
+			return appendKnownHost(path, hostname, key)
+		default:
+			return fmt.Errorf("host key for %s rejected: %w", hostname, err)
+		}
+	}, nil
+}
+
+// hostKeyChanged 判断 known_hosts 中是否记录了同类型的其他密钥
+// 只记录了其他类型的密钥时，与 OpenSSH 一样视为未知的新密钥，而不是密钥变更
+func hostKeyChanged(want []knownhosts.KnownKey, key ssh.PublicKey) bool {
+	for _, known := range want {
+		if known.Key.Type() == key.Type() {
+			return true
+		}
+	}
+	return false
+}
+
+// confirmHostKey 弹窗显示主机密钥指纹并等待用户选择，不能在主线程中调用
+func (w *Window) confirmHostKey(hostname string, key ssh.PublicKey, changed bool) hostKeyDecision {
+	result := make(chan hostKeyDecision, 1)
+	fyne.Do(func() {
+		var message string
+		title := "Unknown Host"
+		if changed {
+			title = "WARNING: HOST KEY CHANGED"
+			message = fmt.Sprintf("The %s host key for %s has changed!\n"+
+				"Someone could be eavesdropping on you right now (man-in-the-middle attack),\n"+
+				"or the host key has just been changed.", key.Type(), hostname)
+		} else {
+			message = fmt.Sprintf("The authenticity of host %s can't be established.", hostname)
+		}
+		info := widget.NewForm(
+			widget.NewFormItem("Key Type", widget.NewLabel(key.Type())),
+			widget.NewFormItem("Fingerprint", widget.NewLabel(ssh.FingerprintSHA256(key))),
+		)
+		content := container.NewVBox(widget.NewLabel(message), info)
+		if changed {
+			warning := widget.NewLabelWithStyle("Connect only if you are sure the new key is legitimate.", fyne.TextAlignLeading, fyne.TextStyle{Bold: true})
+			warning.Importance = widget.DangerImportance
+			content.Add(warning)
+		}
+
+		dlg := dialog.NewCustomWithoutButtons(title, content, w.win)
+		answer := func(d hostKeyDecision) func() {
+			return func() {
+				result <- d
+				dlg.Hide()
+			}
+		}
+		reject := widget.NewButtonWithIcon("Reject", theme.CancelIcon(), answer(hostKeyReject))
+		once := widget.NewButton("Accept Once", answer(hostKeyAcceptOnce))
+		buttons := []fyne.CanvasObject{reject, once}
+		if changed {
+			reject.Importance = widget.HighImportance
+			once.Importance = widget.DangerImportance
+			dlg.SetIcon(theme.WarningIcon())
+		} else {
+			save := widget.NewButtonWithIcon("Accept and Save", theme.ConfirmIcon(), answer(hostKeyAcceptSave))
+			save.Importance = widget.HighImportance
+			buttons = append(buttons, save)
+		}
+		dlg.SetButtons(buttons)
+		dlg.Show()
+	})
+	return <-result
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// 每一跳建立连接和完成握手的超时，握手包括等待用户确认主机密钥和输入验证码的时间
+const (
+	sshDialTimeout      = 10 * time.Second
+	sshHandshakeTimeout = 2 * time.Minute
+)
+
+// jumpChain 返回从第一个跳板机到目标主机的连接链
+// 跳板机自身配置的跳板机会被忽略，连接链以目标配置为准，同一主机不能在链中出现两次
+func (c *SSHConfig) jumpChain(win *Window) ([]*SSHConfig, error) {
+	hops := make([]*SSHConfig, 0, len(c.data.JumpHosts)+1)
+	visited := map[string]bool{c.Name(): true}
+	for _, name := range c.data.JumpHosts {
+		if name == c.Name() {
+			return nil, fmt.Errorf("config %s can not jump through itself", name)
+		}
+		if visited[name] {
+			return nil, fmt.Errorf("jump host %s appears more than once in the chain of %s", name, c.Name())
+		}
+		visited[name] = true
+		cfg := win.findConfig(name)
+		if cfg == nil {
+			return nil, fmt.Errorf("jump host config not found: %s", name)
+		}
+		hop, ok := cfg.(*SSHConfig)
+		if !ok {
+			return nil, fmt.Errorf("jump host config %s is not an SSH config", name)
+		}
+		hops = append(hops, hop)
+	}
+	return append(hops, c), nil
+}
+
+// hopsUseAgent 判断连接链中是否有主机使用 ssh-agent 认证
+// 启用 UseAgent 或在认证顺序中明确指定 agent 都需要连接 agent
+func hopsUseAgent(hops []*SSHConfig) bool {
+	for _, hop := range hops {
+		for _, method := range hop.data.authOrder() {
+			if method == AuthAgent {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// dialChain 依次经过跳板机连接到最后一个主机，每一跳单独认证和校验主机密钥
+// 返回的客户端关闭后，中间的跳板连接也会随之关闭，同时返回最后一个主机的主机密钥
+func dialChain(win *Window, hops []*SSHConfig, ag agent.Agent) (*ssh.Client, ssh.PublicKey, error) {
+	var hostKey ssh.PublicKey
+	var client *ssh.Client
+	clients := make([]*ssh.Client, 0, len(hops))
+	closeAll := func() {
+		for i := len(clients) - 1; i >= 0; i-- {
+			clients[i].Close()
+		}
+	}
+	for _, hop := range hops {
+		cfg, err := hop.clientConfig(win, ag)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		check := cfg.HostKeyCallback
+		cfg.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if err := check(hostname, remote, key); err != nil {
+				return err
+			}
+			hostKey = key
+			return nil
+		}
+		addr := hop.addr()
+		var conn net.Conn
+		if client == nil {
+			conn, err = net.DialTimeout("tcp", addr, cfg.Timeout)
+		} else {
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+			conn, err = client.DialContext(ctx, "tcp", addr)
+			cancel()
+			if err != nil {
+				err = fmt.Errorf("via %s: %w", client.RemoteAddr(), err)
+			}
+		}
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("failed to dial %s: %w", hop.Name(), err)
+		}
+		client, err = handshake(conn, addr, cfg, sshHandshakeTimeout)
+		if err != nil {
+			closeAll()
+			return nil, nil, fmt.Errorf("failed to connect %s: %w", hop.Name(), err)
+		}
+		clients = append(clients, client)
+	}
+
+	if len(clients) > 1 {
+		go func() {
+			client.Wait()
+			closeAll()
+		}()
+	}
+	return client, hostKey, nil
+}
+
+// handshake 在 conn 上完成 SSH 握手，超时后关闭连接
+// 经过跳板机的连接不支持 SetDeadline，通过定时关闭连接中断握手
+func handshake(conn net.Conn, addr string, cfg *ssh.ClientConfig, timeout time.Duration) (*ssh.Client, error) {
+	timer := time.AfterFunc(timeout, func() {
+		conn.Close()
+	})
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, cfg)
+	if !timer.Stop() {
+		if err == nil {
+			sshConn.Close()
+		}
+		return nil, fmt.Errorf("ssh handshake timed out after %s", timeout)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(sshConn, chans, reqs), nil
+}
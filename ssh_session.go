@@ -0,0 +1,381 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"github.com/fyne-io/terminal"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+const (
+	defaultKeepAliveInterval = 30 // 默认心跳间隔（秒）
+	keepAliveMaxMissed       = 3  // 连续心跳失败次数达到该值时认为连接已断开
+	reconnectAttempts        = 5  // 自动重连的最大次数
+)
+
+// sshSession 一次 SSH 连接及其上的交互式 shell
+type sshSession struct {
+	conn        *ssh.Client
+	session     *ssh.Session
+	in          io.WriteCloser
+	out         io.Reader
+	agentCloser io.Closer
+	info        []InfoItem
+}
+
+// Close 关闭会话和连接
+func (s *sshSession) Close() {
+	s.session.Close()
+	s.conn.Close()
+	if s.agentCloser != nil {
+		s.agentCloser.Close()
+	}
+}
+
+// openSession 建立连接并以指定大小启动 shell，不能在主线程中调用
+func (c *SSHConfig) openSession(win *Window, rows, cols uint) (*sshSession, error) {
+	conf := c.data
+
+	hops, err := c.jumpChain(win)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &sshSession{}
+	var ag agent.ExtendedAgent
+	if conf.ForwardAgent || hopsUseAgent(hops) {
+		ag, s.agentCloser, err = openAgent()
+		if err != nil {
+			// agent 不可用时继续使用其他认证方式
+			log.Printf("ssh-agent unavailable: %v", err)
+		}
+	}
+	fail := func(err error) (*sshSession, error) {
+		if s.conn != nil {
+			s.conn.Close()
+		}
+		if s.agentCloser != nil {
+			s.agentCloser.Close()
+		}
+		return nil, err
+	}
+
+	var hostKey ssh.PublicKey
+	s.conn, hostKey, err = dialChain(win, hops, ag)
+	if err != nil {
+		return fail(err)
+	}
+	s.info = c.connInfo(s.conn, hostKey)
+	s.session, err = s.conn.NewSession()
+	if err != nil {
+		return fail(err)
+	}
+	if conf.ForwardAgent && ag != nil {
+		if err := forwardAgent(s.conn, s.session, ag); err != nil {
+			log.Printf("Failed to forward agent: %v", err)
+		}
+	} else if s.agentCloser != nil {
+		// 只用于认证时，连接建立后即可关闭 agent
+		s.agentCloser.Close()
+		s.agentCloser = nil
+	}
+
+	modes := ssh.TerminalModes{
+		ssh.ECHO:          0,     // disable echoing
+		ssh.TTY_OP_ISPEED: 14400, // input speed = 14.4kbaud
+		ssh.TTY_OP_OSPEED: 14400, // output speed = 14.4kbaud
+	}
+	if err = s.session.RequestPty("xterm-color", int(rows), int(cols), modes); err != nil {
+		return fail(err)
+	}
+	if s.in, err = s.session.StdinPipe(); err != nil {
+		return fail(err)
+	}
+	if s.out, err = s.session.StdoutPipe(); err != nil {
+		return fail(err)
+	}
+	if err = s.session.Shell(); err != nil {
+		return fail(err)
+	}
+	return s, nil
+}
+
+// connInfo 返回连接信息，包括主机密钥和证书的有效期、principals
+func (c *SSHConfig) connInfo(conn *ssh.Client, hostKey ssh.PublicKey) []InfoItem {
+	info := []InfoItem{
+		{Name: "Remote", Value: conn.RemoteAddr().String()},
+		{Name: "User", Value: conn.User()},
+		{Name: "Server", Value: string(conn.ServerVersion())},
+	}
+	if hostKey != nil {
+		if cert, ok := hostKey.(*ssh.Certificate); ok {
+			info = append(info, InfoItem{Name: "Host Key", Value: cert.Key.Type() + " " + ssh.FingerprintSHA256(cert.Key)})
+			info = append(info, certInfo("Host Cert", cert)...)
+		} else {
+			info = append(info, InfoItem{Name: "Host Key", Value: hostKey.Type() + " " + ssh.FingerprintSHA256(hostKey)})
+		}
+	}
+	if c.data.CertPath != "" {
+		if cert, err := loadCertificate(c.data.CertPath, ssh.UserCert); err == nil {
+			info = append(info, certInfo("User Cert", cert)...)
+		}
+	}
+	return info
+}
+
+// keepAliveInterval 返回心跳间隔，小于 0 表示关闭心跳
+func (d *SSHConfigData) keepAliveInterval() time.Duration {
+	if d.KeepAlive < 0 {
+		return 0
+	}
+	if d.KeepAlive == 0 {
+		return defaultKeepAliveInterval * time.Second
+	}
+	return time.Duration(d.KeepAlive) * time.Second
+}
+
+// sendKeepAlive 发送 keepalive@openssh.com 请求并等待回复
+func sendKeepAlive(conn ssh.Conn, timeout time.Duration) error {
+	result := make(chan error, 1)
+	go func() {
+		_, _, err := conn.SendRequest("keepalive@openssh.com", true, nil)
+		result <- err
+	}()
+	select {
+	case err := <-result:
+		return err
+	case <-time.After(timeout):
+		return errors.New("keepalive timeout")
+	}
+}
+
+// keepAlive 定时发送心跳，连续失败时关闭连接，让读取端感知断开
+func keepAlive(conn ssh.Conn, interval time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	missed := 0
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := sendKeepAlive(conn, interval); err != nil {
+				missed++
+				log.Printf("SSH keepalive failed (%d/%d): %v", missed, keepAliveMaxMissed, err)
+				if missed >= keepAliveMaxMissed {
+					conn.Close()
+					return
+				}
+			} else {
+				missed = 0
+			}
+		}
+	}
+}
+
+// isDropped 判断会话是否因连接中断而结束，正常退出 shell 不算断开
+func isDropped(waitErr error) bool {
+	if waitErr == nil {
+		return false
+	}
+	var exitErr *ssh.ExitError
+	return !errors.As(waitErr, &exitErr)
+}
+
+// sshTerm 管理终端标签页对应的 SSH 会话，断开后可在同一个终端上重连
+type sshTerm struct {
+	config *SSHConfig
+	win    *Window
+	term   *Term
+	panel  *SFTPPanel
+
+	lock       sync.Mutex
+	current    *sshSession
+	tunnels    []activeForward
+	closed     bool
+	connecting bool
+}
+
+// attach 在终端上运行会话，并启动端口转发和心跳
+func (t *sshTerm) attach(s *sshSession) {
+	conf := t.config.data
+
+	t.lock.Lock()
+	t.current = s
+	t.tunnels = make([]activeForward, 0, len(conf.Forwards))
+	// 启动端口转发，单条失败不影响会话
+	for _, rule := range conf.Forwards {
+		tunnel, err := startTunnel(conf.Name, s.conn, rule)
+		if err != nil {
+			log.Println(err)
+			t.win.showError(err)
+			continue
+		}
+		t.tunnels = append(t.tunnels, tunnel)
+	}
+	t.win.addTunnels(t.tunnels...)
+	t.lock.Unlock()
+
+	done := make(chan struct{})
+	if interval := conf.keepAliveInterval(); interval > 0 {
+		go keepAlive(s.conn, interval, done)
+	}
+	t.term.SetInfo(s.info)
+	t.term.SetStat(TermStatConnected)
+
+	go func() {
+		err := t.term.RunWithReaderAndWriter(s.in, s.out)
+		if err != nil {
+			log.Println(err)
+		}
+		close(done)
+		dropped := isDropped(s.session.Wait())
+		t.detach(s)
+		if dropped && conf.AutoReconnect {
+			t.autoReconnect()
+		}
+	}()
+}
+
+// detach 会话结束后释放资源，并将终端标记为断开
+func (t *sshTerm) detach(s *sshSession) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.current != s {
+		return
+	}
+	t.win.removeTunnels(t.tunnels...)
+	t.tunnels = nil
+	t.current = nil
+	s.Close()
+	if !t.closed {
+		t.term.SetStat(TermStatDisconnected)
+	}
+}
+
+// reconnect 重新建立会话并复用原终端，不能在主线程中调用
+func (t *sshTerm) reconnect() error {
+	t.lock.Lock()
+	if t.closed || t.current != nil || t.connecting {
+		t.lock.Unlock()
+		return nil
+	}
+	t.connecting = true
+	t.lock.Unlock()
+	defer func() {
+		t.lock.Lock()
+		t.connecting = false
+		t.lock.Unlock()
+	}()
+
+	t.term.SetStat(TermStatConnecting)
+	// Size 加锁读取最近一次的终端大小，可以在后台调用
+	rows, cols := t.term.Size()
+	s, err := t.config.openSession(t.win, rows, cols)
+	if err != nil {
+		t.term.SetStat(TermStatDisconnected)
+		return err
+	}
+
+	t.lock.Lock()
+	closed := t.closed
+	t.lock.Unlock()
+	if closed {
+		s.Close()
+		return nil
+	}
+	if t.panel != nil {
+		if err := t.panel.Reconnect(s.conn); err != nil {
+			log.Println(err)
+		}
+	}
+	t.attach(s)
+	return nil
+}
+
+// autoReconnect 连接中断后按指数退避自动重连
+func (t *sshTerm) autoReconnect() {
+	delay := 2 * time.Second
+	for i := 1; i <= reconnectAttempts; i++ {
+		log.Printf("Reconnecting %s in %s (%d/%d)", t.config.Name(), delay, i, reconnectAttempts)
+		time.Sleep(delay)
+		err := t.reconnect()
+		if err == nil {
+			return
+		}
+		log.Printf("Reconnect %s failed: %v", t.config.Name(), err)
+		delay *= 2
+	}
+	t.win.showError(fmt.Errorf("failed to reconnect %s after %d attempts", t.config.Name(), reconnectAttempts))
+}
+
+// windowChange 将终端大小同步到当前会话
+func (t *sshTerm) windowChange(config *terminal.Config) {
+	t.lock.Lock()
+	s := t.current
+	t.lock.Unlock()
+	if s == nil || config.Rows == 0 || config.Columns == 0 {
+		return
+	}
+	if err := s.session.WindowChange(int(config.Rows), int(config.Columns)); err != nil {
+		log.Println(err)
+	}
+}
+
+// close 标签页关闭时释放所有资源
+func (t *sshTerm) close() {
+	t.lock.Lock()
+	t.closed = true
+	s := t.current
+	t.lock.Unlock()
+	if s != nil {
+		t.detach(s)
+	}
+	if t.panel != nil {
+		t.panel.Close()
+	}
+}
+
+func (c *SSHConfig) connect(win *Window) {
+	term := NewTerm(c.data.Name, c)
+	// 按终端标签页的实际大小申请 PTY
+	var rows, cols uint
+	fyne.DoAndWait(func() {
+		rows, cols = term.Fit(win.termSize())
+	})
+
+	s, err := c.openSession(win, rows, cols)
+	if err != nil {
+		log.Println(err)
+		win.showError(err)
+		return
+	}
+
+	st := &sshTerm{config: c, win: win, term: term}
+	// 在同一连接上打开 SFTP，服务端不支持时只记录日志
+	st.panel, err = NewSFTPPanel(win, s.conn)
+	if err != nil {
+		log.Println(err)
+	}
+
+	term.AddConfigListener(st.windowChange)
+	term.AddCloseListener(st.close)
+	term.SetReconnect(st.reconnect)
+	st.attach(s)
+
+	fyne.Do(func() {
+		if st.panel != nil {
+			side := st.panel.Content()
+			side.Hide()
+			term.SetSidePanel(side)
+		}
+		win.AddTermTab(term)
+	})
+}
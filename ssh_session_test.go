@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// TestSendKeepAlive 测试向进程内服务发送心跳，服务端回复失败也视为连接正常
+func TestSendKeepAlive(t *testing.T) {
+	addr := newTestSSHServer(t, &ssh.ServerConfig{NoClientAuth: true})
+	client, err := dialTestSSHServer(addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	if err := sendKeepAlive(client, time.Second); err != nil {
+		t.Fatalf("sendKeepAlive failed: %v", err)
+	}
+	client.Close()
+	if err := sendKeepAlive(client, time.Second); err == nil {
+		t.Fatal("expected error after connection closed")
+	}
+}
+
+// deadConn 模拟已失去响应的连接，心跳请求永远不会返回
+type deadConn struct {
+	ssh.Conn
+	closed atomic.Bool
+}
+
+func (c *deadConn) SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+	select {}
+}
+
+func (c *deadConn) Close() error {
+	c.closed.Store(true)
+	return nil
+}
+
+// TestKeepAliveClosesDeadConn 测试连续心跳超时后关闭连接
+func TestKeepAliveClosesDeadConn(t *testing.T) {
+	conn := &deadConn{}
+	done := make(chan struct{})
+	defer close(done)
+	go keepAlive(conn, 10*time.Millisecond, done)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !conn.closed.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("dead connection was not closed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestKeepAliveInterval(t *testing.T) {
+	tests := []struct {
+		keepAlive int
+		want      time.Duration
+	}{
+		{0, defaultKeepAliveInterval * time.Second},
+		{15, 15 * time.Second},
+		{-1, 0},
+	}
+	for _, tt := range tests {
+		d := &SSHConfigData{KeepAlive: tt.keepAlive}
+		if got := d.keepAliveInterval(); got != tt.want {
+			t.Errorf("keepAliveInterval(%d) = %v, want %v", tt.keepAlive, got, tt.want)
+		}
+	}
+}
+
+func TestIsDropped(t *testing.T) {
+	if isDropped(nil) {
+		t.Error("nil error should not be treated as dropped")
+	}
+	if isDropped(&ssh.ExitError{}) {
+		t.Error("exit status should not be treated as dropped")
+	}
+	if !isDropped(&ssh.ExitMissingError{}) {
+		t.Error("missing exit status should be treated as dropped")
+	}
+	if !isDropped(errors.New("connection reset")) {
+		t.Error("transport error should be treated as dropped")
+	}
+}
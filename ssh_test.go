@@ -0,0 +1,737 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/pem"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// newTestKeyPEM 生成测试用的 ed25519 私钥 PEM，passphrase 不为空时加密
+func newTestKeyPEM(t *testing.T, passphrase string) (ed25519.PublicKey, []byte) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	var block *pem.Block
+	if passphrase == "" {
+		block, err = ssh.MarshalPrivateKey(priv, "")
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(priv, "", []byte(passphrase))
+	}
+	if err != nil {
+		t.Fatalf("MarshalPrivateKey failed: %v", err)
+	}
+	return pub, pem.EncodeToMemory(block)
+}
+
+// TestParseAuthOrder 测试认证顺序解析
+func TestParseAuthOrder(t *testing.T) {
+	order, err := parseAuthOrder(" Key , password ")
+	if err != nil {
+		t.Fatalf("parseAuthOrder failed: %v", err)
+	}
+	if len(order) != 2 || order[0] != AuthKey || order[1] != AuthPassword {
+		t.Errorf("Unexpected auth order: %v", order)
+	}
+
+	if _, err := parseAuthOrder("key,otp"); err == nil {
+		t.Error("parseAuthOrder should fail with unknown method")
+	}
+	if _, err := parseAuthOrder("key,key"); err == nil {
+		t.Error("parseAuthOrder should fail with duplicate method")
+	}
+
+	data := &SSHConfigData{}
+	if got := data.authOrder(); len(got) != len(defaultAuthOrder) || got[0] != AuthKey {
+		t.Errorf("Expected default auth order, got %v", got)
+	}
+}
+
+// TestParsePrivateKey 测试私钥解析
+func TestParsePrivateKey(t *testing.T) {
+	pub, plain := newTestKeyPEM(t, "")
+	signer, err := parsePrivateKey(plain, "", nil)
+	if err != nil {
+		t.Fatalf("parsePrivateKey failed: %v", err)
+	}
+	expected, _ := ssh.NewPublicKey(pub)
+	if string(signer.PublicKey().Marshal()) != string(expected.Marshal()) {
+		t.Error("Parsed key does not match generated key")
+	}
+}
+
+// TestParseEncryptedPrivateKey 测试加密私钥的口令处理
+func TestParseEncryptedPrivateKey(t *testing.T) {
+	_, encrypted := newTestKeyPEM(t, "secret")
+
+	// 使用已保存的口令，不应询问
+	_, err := parsePrivateKey(encrypted, "secret", func() (string, bool) {
+		t.Error("prompt should not be called when passphrase is stored")
+		return "", false
+	})
+	if err != nil {
+		t.Fatalf("parsePrivateKey with stored passphrase failed: %v", err)
+	}
+
+	// 没有保存口令时询问
+	prompted := false
+	_, err = parsePrivateKey(encrypted, "", func() (string, bool) {
+		prompted = true
+		return "secret", true
+	})
+	if err != nil {
+		t.Fatalf("parsePrivateKey with prompted passphrase failed: %v", err)
+	}
+	if !prompted {
+		t.Error("prompt should be called when passphrase is missing")
+	}
+
+	// 取消输入
+	if _, err = parsePrivateKey(encrypted, "", func() (string, bool) { return "", false }); err == nil {
+		t.Error("parsePrivateKey should fail when prompt is canceled")
+	}
+
+	// 错误口令
+	if _, err = parsePrivateKey(encrypted, "wrong", nil); err == nil {
+		t.Error("parsePrivateKey should fail with wrong passphrase")
+	}
+}
+
+// TestSSHConfigDataKeyEncryption 测试内联私钥和口令加密存储
+func TestSSHConfigDataKeyEncryption(t *testing.T) {
+	_, key := newTestKeyPEM(t, "")
+	config := &SSHConfigData{}
+
+	if _, err := config.setKey(string(key)); err != nil {
+		t.Fatalf("setKey failed: %v", err)
+	}
+	if _, err := config.setPassphrase("secret"); err != nil {
+		t.Fatalf("setPassphrase failed: %v", err)
+	}
+	if config.Key == string(key) || config.Passphrase == "secret" {
+		t.Error("Stored key and passphrase should be encrypted")
+	}
+
+	got, err := config.keyBytes()
+	if err != nil {
+		t.Fatalf("keyBytes failed: %v", err)
+	}
+	if string(got) != string(key) {
+		t.Error("keyBytes should return the decrypted inline key")
+	}
+	passphrase, err := config.getPassphrase()
+	if err != nil || passphrase != "secret" {
+		t.Errorf("getPassphrase got=%q err=%v", passphrase, err)
+	}
+}
+
+// newTestSSHServer 启动进程内 SSH 服务，返回监听地址
+// 服务端只支持 direct-tcpip 和 direct-streamlocal 通道，用于验证握手、认证和端口转发
+func newTestSSHServer(t *testing.T, config *ssh.ServerConfig) string {
+	t.Helper()
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatalf("NewSignerFromKey failed: %v", err)
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(conn, config)
+				if err != nil {
+					conn.Close()
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for ch := range chans {
+					switch ch.ChannelType() {
+					case "direct-tcpip":
+						go handleTestDirectTCPIP(ch)
+					case "direct-streamlocal@openssh.com":
+						go handleTestDirectStreamLocal(ch)
+					default:
+						ch.Reject(ssh.UnknownChannelType, "not supported")
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+// handleTestDirectTCPIP 处理 direct-tcpip 通道，连接目标地址并转发数据
+func handleTestDirectTCPIP(newCh ssh.NewChannel) {
+	var payload struct {
+		Host     string
+		Port     uint32
+		OrigHost string
+		OrigPort uint32
+	}
+	if err := ssh.Unmarshal(newCh.ExtraData(), &payload); err != nil {
+		newCh.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	target, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		newCh.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	ch, reqs, err := newCh.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	go func() {
+		io.Copy(ch, target)
+		ch.CloseWrite()
+	}()
+	io.Copy(target, ch)
+	target.Close()
+}
+
+// handleTestDirectStreamLocal 处理 direct-streamlocal 通道，连接 unix socket 并转发数据
+func handleTestDirectStreamLocal(newCh ssh.NewChannel) {
+	var payload struct {
+		SocketPath string
+		Reserved0  string
+		Reserved1  uint32
+	}
+	if err := ssh.Unmarshal(newCh.ExtraData(), &payload); err != nil {
+		newCh.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	target, err := net.Dial("unix", payload.SocketPath)
+	if err != nil {
+		newCh.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	ch, reqs, err := newCh.Accept()
+	if err != nil {
+		target.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	go func() {
+		io.Copy(ch, target)
+		ch.CloseWrite()
+	}()
+	io.Copy(target, ch)
+	target.Close()
+}
+
+// dialTestSSHServer 使用给定认证方式连接测试服务
+func dialTestSSHServer(addr string, auth ...ssh.AuthMethod) (*ssh.Client, error) {
+	return ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User:            "test",
+		Auth:            auth,
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		Timeout:         5 * time.Second,
+	})
+}
+
+// TestAgentAuth 测试通过进程内 agent keyring 进行公钥认证
+func TestAgentAuth(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	authorized, _ := ssh.NewPublicKey(pub)
+
+	addr := newTestSSHServer(t, &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unauthorized key")
+		},
+	})
+
+	// 空 keyring 认证失败
+	keyring := agent.NewKeyring()
+	if client, err := dialTestSSHServer(addr, agentAuthMethod(keyring)); err == nil {
+		client.Close()
+		t.Fatal("Auth should fail with empty agent")
+	}
+
+	if err := keyring.Add(agent.AddedKey{PrivateKey: priv}); err != nil {
+		t.Fatalf("Add key to agent failed: %v", err)
+	}
+	client, err := dialTestSSHServer(addr, agentAuthMethod(keyring))
+	if err != nil {
+		t.Fatalf("Auth with agent failed: %v", err)
+	}
+	client.Close()
+}
+
+// TestAgentWithWrongKeyFallsBackToConfiguredKey 测试 agent 中只有无关密钥时仍然使用配置的私钥认证
+func TestAgentWithWrongKeyFallsBackToConfiguredKey(t *testing.T) {
+	pub, keyPEM := newTestKeyPEM(t, "")
+	authorized, _ := ssh.NewPublicKey(pub)
+	addr := newTestSSHServer(t, &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if bytes.Equal(key.Marshal(), authorized.Marshal()) {
+				return nil, nil
+			}
+			return nil, errors.New("unauthorized key")
+		},
+	})
+
+	_, wrong, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	keyring := agent.NewKeyring()
+	if err := keyring.Add(agent.AddedKey{PrivateKey: wrong}); err != nil {
+		t.Fatalf("Add key to agent failed: %v", err)
+	}
+
+	keyPath := filepath.Join(t.TempDir(), "id_ed25519")
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	cfg := &SSHConfig{data: &SSHConfigData{Name: "test", UseAgent: true, KeyPath: keyPath}}
+	auth, err := cfg.authMethods(&Window{}, keyring)
+	if err != nil {
+		t.Fatalf("authMethods failed: %v", err)
+	}
+	client, err := dialTestSSHServer(addr, auth...)
+	if err != nil {
+		t.Fatalf("Auth with configured key after agent failed: %v", err)
+	}
+	client.Close()
+
+	// 没有 agent 时只使用私钥
+	auth, err = cfg.authMethods(&Window{}, nil)
+	if err != nil {
+		t.Fatalf("authMethods without agent failed: %v", err)
+	}
+	client, err = dialTestSSHServer(addr, auth...)
+	if err != nil {
+		t.Fatalf("Auth without agent failed: %v", err)
+	}
+	client.Close()
+}
+
+// TestUnusableKeyFallsBackToPassword 测试私钥无法读取或解析时跳过，继续使用密码认证
+func TestUnusableKeyFallsBackToPassword(t *testing.T) {
+	addr := newTestSSHServer(t, &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("wrong password")
+		},
+	})
+	dir := t.TempDir()
+	invalidPath := filepath.Join(dir, "invalid")
+	if err := os.WriteFile(invalidPath, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, keyPath := range []string{filepath.Join(dir, "missing"), invalidPath} {
+		data := &SSHConfigData{Name: "test", KeyPath: keyPath, AuthOrder: []string{AuthKey, AuthPassword}}
+		if _, err := data.setPassword("secret"); err != nil {
+			t.Fatal(err)
+		}
+		cfg := &SSHConfig{data: data}
+		auth, err := cfg.authMethods(&Window{}, nil)
+		if err != nil {
+			t.Fatalf("authMethods with %s failed: %v", keyPath, err)
+		}
+		client, err := dialTestSSHServer(addr, auth...)
+		if err != nil {
+			t.Fatalf("Password auth after unusable key %s failed: %v", keyPath, err)
+		}
+		client.Close()
+
+		// 只有不可用的私钥时没有可用的认证方式
+		data.AuthOrder = []string{AuthKey}
+		if _, err := cfg.authMethods(&Window{}, nil); err == nil {
+			t.Errorf("Unusable key %s only should fail", keyPath)
+		}
+	}
+}
+
+// TestCustomAuthOrder 测试与默认顺序相同的认证顺序不保存
+func TestCustomAuthOrder(t *testing.T) {
+	for _, order := range [][]string{nil, {}, defaultAuthOrder, {AuthKey, AuthPassword}} {
+		if got := customAuthOrder(order); got != nil {
+			t.Errorf("customAuthOrder(%v) = %v, expected nil", order, got)
+		}
+	}
+	// 早期表单保存的默认顺序使用当前的默认顺序
+	data := &SSHConfigData{AuthOrder: []string{AuthKey, AuthPassword}}
+	if got := data.authOrder(); !sameAuthOrder(got, defaultAuthOrder) {
+		t.Errorf("Legacy default auth order should use the current default, got %v", got)
+	}
+	order := []string{AuthPassword, AuthKey}
+	if got := customAuthOrder(order); !sameAuthOrder(got, order) {
+		t.Errorf("Custom auth order should be kept, got %v", got)
+	}
+}
+
+// TestAuthOrderWithAgent 测试启用 agent 时的认证顺序
+func TestAuthOrderWithAgent(t *testing.T) {
+	data := &SSHConfigData{UseAgent: true}
+	order := data.authOrder()
+	if len(order) != len(defaultAuthOrder)+1 || order[0] != AuthAgent {
+		t.Errorf("Expected agent to be tried first, got %v", order)
+	}
+
+	data.AuthOrder = []string{AuthKey, AuthAgent}
+	order = data.authOrder()
+	if len(order) != 2 || order[1] != AuthAgent {
+		t.Errorf("Explicit auth order should be kept, got %v", order)
+	}
+
+	// 认证顺序中明确指定 agent 时，即使未启用 UseAgent 也需要连接 agent
+	hops := []*SSHConfig{{data: &SSHConfigData{}}, {data: &SSHConfigData{AuthOrder: []string{AuthAgent, AuthPassword}}}}
+	if !hopsUseAgent(hops) {
+		t.Error("Explicit agent in auth order should use agent")
+	}
+	if hopsUseAgent(hops[:1]) {
+		t.Error("Default auth order should not use agent")
+	}
+}
+
+// TestKeyboardInteractiveAuth 测试交互式认证，已保存的密码回答第一个问题，OTP 由用户输入
+func TestKeyboardInteractiveAuth(t *testing.T) {
+	addr := newTestSSHServer(t, &ssh.ServerConfig{
+		KeyboardInteractiveCallback: func(conn ssh.ConnMetadata, challenge ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			answers, err := challenge("", "", []string{"Password: "}, []bool{false})
+			if err != nil {
+				return nil, err
+			}
+			if len(answers) != 1 || answers[0] != "secret" {
+				return nil, errors.New("wrong password")
+			}
+			answers, err = challenge("OTP", "Enter the code from your device", []string{"Verification code: ", "Remember device? "}, []bool{false, true})
+			if err != nil {
+				return nil, err
+			}
+			if len(answers) != 2 || answers[0] != "123456" {
+				return nil, errors.New("wrong verification code")
+			}
+			return nil, nil
+		},
+	})
+
+	var prompts [][]string
+	prompt := func(name, instruction string, questions []string, echos []bool) ([]string, bool) {
+		prompts = append(prompts, questions)
+		if len(echos) != 2 || echos[0] || !echos[1] {
+			t.Errorf("Unexpected echos: %v", echos)
+		}
+		return []string{"123456", "no"}, true
+	}
+	client, err := dialTestSSHServer(addr, ssh.KeyboardInteractive(keyboardInteractive("secret", prompt)))
+	if err != nil {
+		t.Fatalf("Keyboard-interactive auth failed: %v", err)
+	}
+	client.Close()
+	if len(prompts) != 1 || prompts[0][0] != "Verification code: " {
+		t.Errorf("Stored password should answer the first prompt, user was asked %v", prompts)
+	}
+
+	// 没有保存密码时所有问题都交给用户，取消时认证失败
+	canceled := func(name, instruction string, questions []string, echos []bool) ([]string, bool) {
+		return nil, false
+	}
+	if client, err := dialTestSSHServer(addr, ssh.KeyboardInteractive(keyboardInteractive("", canceled))); err == nil {
+		client.Close()
+		t.Fatal("Auth should fail when prompt is canceled")
+	}
+}
+
+// TestJumpChain 测试跳板机配置解析
+func TestJumpChain(t *testing.T) {
+	bastion := &SSHConfig{data: &SSHConfigData{Name: "bastion", Type: "ssh"}}
+	inner := &SSHConfig{data: &SSHConfigData{Name: "inner", Type: "ssh"}}
+	docker := &DockerConfig{data: &DockerConfigData{Name: "docker", Type: "docker"}}
+	target := &SSHConfig{data: &SSHConfigData{Name: "target", Type: "ssh", JumpHosts: []string{"bastion", "inner"}}}
+	win := &Window{confs: []Config{bastion, inner, docker, target}}
+
+	hops, err := target.jumpChain(win)
+	if err != nil {
+		t.Fatalf("jumpChain failed: %v", err)
+	}
+	if len(hops) != 3 || hops[0] != bastion || hops[1] != inner || hops[2] != target {
+		t.Errorf("Unexpected jump chain: %v", hops)
+	}
+
+	target.data.JumpHosts = []string{"missing"}
+	if _, err := target.jumpChain(win); err == nil {
+		t.Error("jumpChain should fail with missing config")
+	}
+	target.data.JumpHosts = []string{"docker"}
+	if _, err := target.jumpChain(win); err == nil {
+		t.Error("jumpChain should fail with non-SSH config")
+	}
+	target.data.JumpHosts = []string{"target"}
+	if _, err := target.jumpChain(win); err == nil {
+		t.Error("jumpChain should fail when jumping through itself")
+	}
+	target.data.JumpHosts = []string{"bastion", "inner", "bastion"}
+	if _, err := target.jumpChain(win); err == nil {
+		t.Error("jumpChain should fail when a jump host repeats")
+	}
+}
+
+// testHostKey 返回测试服务器的主机密钥
+func testHostKey(t *testing.T, addr string) ssh.PublicKey {
+	t.Helper()
+	var hostKey ssh.PublicKey
+	errGotKey := errors.New("got host key")
+	_, err := ssh.Dial("tcp", addr, &ssh.ClientConfig{
+		User: "test",
+		HostKeyCallback: func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			hostKey = key
+			return errGotKey
+		},
+		Timeout: 5 * time.Second,
+	})
+	if !errors.Is(err, errGotKey) {
+		t.Fatalf("Failed to get host key of %s: %v", addr, err)
+	}
+	return hostKey
+}
+
+// newTestPasswordHop 启动使用密码认证的测试服务器，返回对应的 SSH 配置，主机密钥写入 known_hosts
+func newTestPasswordHop(t *testing.T, name, password string, logins *atomic.Int32) (*SSHConfig, ssh.PublicKey) {
+	t.Helper()
+	addr := newTestSSHServer(t, &ssh.ServerConfig{
+		PasswordCallback: func(conn ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
+			if string(pass) != password {
+				return nil, errors.New("wrong password")
+			}
+			logins.Add(1)
+			return nil, nil
+		},
+	})
+	hostKey := testHostKey(t, addr)
+	if err := appendKnownHost(knownHostsPath(), addr, hostKey); err != nil {
+		t.Fatalf("appendKnownHost failed: %v", err)
+	}
+	host, port, _ := net.SplitHostPort(addr)
+	data := &SSHConfigData{Name: name, Type: "ssh", Host: host, User: "test", AuthOrder: []string{AuthPassword}}
+	data.Port, _ = strconv.Atoi(port)
+	if _, err := data.setPassword(password); err != nil {
+		t.Fatal(err)
+	}
+	return &SSHConfig{data: data}, hostKey
+}
+
+// TestDialChain 测试经过跳板机连接目标主机，每一跳使用各自的认证和主机密钥
+func TestDialChain(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := ensureKnownHosts(knownHostsPath()); err != nil {
+		t.Fatal(err)
+	}
+	var bastionLogins, targetLogins atomic.Int32
+	bastion, _ := newTestPasswordHop(t, "bastion", "bastion-secret", &bastionLogins)
+	target, targetKey := newTestPasswordHop(t, "target", "target-secret", &targetLogins)
+	target.data.JumpHosts = []string{"bastion"}
+	win := &Window{confs: []Config{bastion, target}}
+
+	hops, err := target.jumpChain(win)
+	if err != nil {
+		t.Fatalf("jumpChain failed: %v", err)
+	}
+	client, hostKey, err := dialChain(win, hops, nil)
+	if err != nil {
+		t.Fatalf("dialChain failed: %v", err)
+	}
+	defer client.Close()
+	if bastionLogins.Load() != 1 || targetLogins.Load() != 1 {
+		t.Errorf("Expected one login on each hop, got bastion=%d target=%d", bastionLogins.Load(), targetLogins.Load())
+	}
+	if hostKey == nil || !bytes.Equal(hostKey.Marshal(), targetKey.Marshal()) {
+		t.Error("dialChain should return the host key of the last hop")
+	}
+
+	// 经过目标主机继续连接跳板机，确认最后的客户端可以正常使用
+	conn, err := client.Dial("tcp", bastion.addr())
+	if err != nil {
+		t.Fatalf("Dial through chain failed: %v", err)
+	}
+	conn.Close()
+
+	// 目标主机认证失败时返回错误并关闭已建立的跳板连接
+	if _, err := target.data.setPassword("wrong"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := dialChain(win, hops, nil); err == nil {
+		t.Error("dialChain should fail when the last hop rejects auth")
+	}
+}
+
+// TestHandshakeTimeout 测试服务端不响应时握手超时
+func TestHandshakeTimeout(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		// 接受连接但不发送 SSH 版本
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			io.Copy(io.Discard, conn)
+		}
+	}()
+
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	cfg := &ssh.ClientConfig{User: "test", HostKeyCallback: ssh.InsecureIgnoreHostKey()}
+	start := time.Now()
+	if _, err := handshake(conn, listener.Addr().String(), cfg, 100*time.Millisecond); err == nil {
+		t.Fatal("handshake should time out")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("handshake took %s", elapsed)
+	}
+}
+
+// TestHostKeyCallback 测试主机密钥首次信任和变更检测
+func TestHostKeyCallback(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssh", "known_hosts")
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := ssh.NewPublicKey(pub)
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+
+	var prompts int
+	decision := hostKeyReject
+	callback, err := newHostKeyCallback(path, func(hostname string, k ssh.PublicKey, changed bool) hostKeyDecision {
+		prompts++
+		if changed {
+			t.Error("Unknown host should not be reported as changed")
+		}
+		return decision
+	})
+	if err != nil {
+		t.Fatalf("newHostKeyCallback failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("known_hosts should be created: %v", err)
+	}
+
+	if err := callback("example.com:22", remote, key); err == nil {
+		t.Error("Rejected host key should fail")
+	}
+	decision = hostKeyAcceptOnce
+	if err := callback("example.com:22", remote, key); err != nil {
+		t.Errorf("Accepted host key should pass: %v", err)
+	}
+	decision = hostKeyAcceptSave
+	if err := callback("example.com:22", remote, key); err != nil {
+		t.Errorf("Saved host key should pass: %v", err)
+	}
+	if prompts != 3 {
+		t.Errorf("Expected 3 prompts, got %d", prompts)
+	}
+
+	// 重新加载后已保存的主机不再询问，变更的密钥被标记
+	changedPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	changedKey, _ := ssh.NewPublicKey(changedPub)
+	var changedReported bool
+	callback, err = newHostKeyCallback(path, func(hostname string, k ssh.PublicKey, changed bool) hostKeyDecision {
+		changedReported = changed
+		return hostKeyReject
+	})
+	if err != nil {
+		t.Fatalf("newHostKeyCallback failed: %v", err)
+	}
+	if err := callback("example.com:22", remote, key); err != nil {
+		t.Errorf("Known host key should pass: %v", err)
+	}
+	if err := callback("example.com:22", remote, changedKey); err == nil {
+		t.Error("Changed host key should be rejected")
+	}
+	if !changedReported {
+		t.Error("Changed host key should be reported")
+	}
+}
+
+// TestHostKeyCallbackOtherKeyType 测试 known_hosts 中只有其他类型的密钥时视为未知密钥而不是变更
+func TestHostKeyCallbackOtherKeyType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known_hosts")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	rsaPub, _ := ssh.NewPublicKey(&rsaKey.PublicKey)
+	if err := appendKnownHost(path, "example.com:22", rsaPub); err != nil {
+		t.Fatalf("appendKnownHost failed: %v", err)
+	}
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := ssh.NewPublicKey(pub)
+	remote := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 22}
+
+	var prompts int
+	callback, err := newHostKeyCallback(path, func(hostname string, k ssh.PublicKey, changed bool) hostKeyDecision {
+		prompts++
+		if changed {
+			t.Error("Host key of another type should not be reported as changed")
+		}
+		return hostKeyAcceptSave
+	})
+	if err != nil {
+		t.Fatalf("newHostKeyCallback failed: %v", err)
+	}
+	if err := callback("example.com:22", remote, key); err != nil {
+		t.Errorf("Accepted host key should pass: %v", err)
+	}
+
+	// 保存后两种类型的密钥都可以通过校验
+	callback, err = newHostKeyCallback(path, func(hostname string, k ssh.PublicKey, changed bool) hostKeyDecision {
+		prompts++
+		return hostKeyReject
+	})
+	if err != nil {
+		t.Fatalf("newHostKeyCallback failed: %v", err)
+	}
+	if err := callback("example.com:22", remote, key); err != nil {
+		t.Errorf("Saved ed25519 host key should pass: %v", err)
+	}
+	if err := callback("example.com:22", remote, rsaPub); err != nil {
+		t.Errorf("Known RSA host key should pass: %v", err)
+	}
+	if prompts != 1 {
+		t.Errorf("Expected 1 prompt, got %d", prompts)
+	}
+}
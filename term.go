@@ -1,21 +1,44 @@
 package main
 
 import (
+	"fyne.io/fyne/v2"
 	"github.com/fyne-io/terminal"
 	"io"
 	"log"
 	"net"
+	"sync"
 )
 
+// 终端连接状态，本地终端始终为空
+const (
+	TermStatConnected    = "connected"
+	TermStatConnecting   = "connecting"
+	TermStatDisconnected = "disconnected"
+)
+
+// InfoItem 连接信息中的一项，例如主机密钥指纹、证书有效期
+type InfoItem struct {
+	Name  string
+	Value string
+}
+
 type Term struct {
 	name            string
 	term            *terminal.Terminal
 	stat            string
 	local           bool
 	sessionConfig   Config
+	configLock      sync.Mutex // 保护 termConfig 和 configListeners，终端大小在后台 goroutine 中更新
 	termConfig      *terminal.Config
 	configListeners []func(*terminal.Config)
 	closeListeners  []func()
+	side            fyne.CanvasObject // 终端旁边的面板，例如 SFTP 文件面板
+	statLock        sync.Mutex
+	statListeners   []func(stat string)
+	reconnect       func() error
+	detach          func() // 关闭标签页时代替发送 EOF，例如 attach 到容器主进程时
+	info            []InfoItem
+	files           containerFiles // 容器会话的文件上传下载，其他会话为空
 }
 
 func NewTerm(name string, cfg Config) *Term {
@@ -29,14 +52,140 @@ func (t *Term) Name() string {
 	return t.name
 }
 
+// SetSidePanel 设置显示在终端旁边的面板，需要在添加标签页前调用
+func (t *Term) SetSidePanel(panel fyne.CanvasObject) {
+	t.side = panel
+}
+
+// ToggleSidePanel 显示或隐藏终端旁边的面板
+func (t *Term) ToggleSidePanel() {
+	if t.side == nil {
+		return
+	}
+	if t.side.Visible() {
+		t.side.Hide()
+	} else {
+		t.side.Show()
+	}
+}
+
+// TermConfig 返回终端配置的副本，未知时返回 nil
 func (t *Term) TermConfig() *terminal.Config {
-	return t.termConfig
+	t.configLock.Lock()
+	defer t.configLock.Unlock()
+	if t.termConfig == nil {
+		return nil
+	}
+	cfg := *t.termConfig
+	return &cfg
 }
 
 func (t *Term) SessionConfig() Config {
 	return t.sessionConfig
 }
 
+// Fit 按给定区域调整终端大小，返回调整后的行数和列数
+// 需要在主线程调用，无法确定大小时返回当前大小或默认的 24x80
+func (t *Term) Fit(size fyne.Size) (rows, cols uint) {
+	rows, cols = t.Size()
+	if size.IsZero() {
+		return
+	}
+
+	// 终端在 Resize 中同步通知监听者，使用带缓冲的通道获取新的大小
+	cfgChan := make(chan terminal.Config, 1)
+	t.term.AddListener(cfgChan)
+	defer t.term.RemoveListener(cfgChan)
+	t.term.Resize(size)
+	select {
+	case cfg := <-cfgChan:
+		if cfg.Rows > 0 && cfg.Columns > 0 {
+			rows, cols = cfg.Rows, cfg.Columns
+		}
+	default:
+	}
+	return
+}
+
+// Size 返回终端当前的行数和列数，未知时返回默认的 24x80，可以在任意线程调用
+func (t *Term) Size() (rows, cols uint) {
+	if cfg := t.TermConfig(); cfg != nil && cfg.Rows > 0 && cfg.Columns > 0 {
+		return cfg.Rows, cfg.Columns
+	}
+	return 24, 80
+}
+
+// Stat 返回终端的连接状态
+func (t *Term) Stat() string {
+	t.statLock.Lock()
+	defer t.statLock.Unlock()
+	return t.stat
+}
+
+// SetStat 更新连接状态并通知监听者，可以在任意线程调用
+func (t *Term) SetStat(stat string) {
+	t.statLock.Lock()
+	if t.stat == stat {
+		t.statLock.Unlock()
+		return
+	}
+	t.stat = stat
+	listeners := t.statListeners
+	t.statLock.Unlock()
+	for _, listener := range listeners {
+		listener(stat)
+	}
+}
+
+// AddStatListener 监听连接状态变化
+func (t *Term) AddStatListener(fn func(stat string)) {
+	if fn != nil {
+		t.statLock.Lock()
+		t.statListeners = append(t.statListeners, fn)
+		t.statLock.Unlock()
+	}
+}
+
+// SetInfo 设置连接信息，每次连接成功后更新
+func (t *Term) SetInfo(info []InfoItem) {
+	t.statLock.Lock()
+	t.info = info
+	t.statLock.Unlock()
+}
+
+// Info 返回连接信息
+func (t *Term) Info() []InfoItem {
+	t.statLock.Lock()
+	defer t.statLock.Unlock()
+	return t.info
+}
+
+// SetFiles 设置容器会话的文件上传下载方法
+func (t *Term) SetFiles(files containerFiles) {
+	t.files = files
+}
+
+// Files 返回容器会话的文件上传下载方法，不支持时返回 nil
+func (t *Term) Files() containerFiles {
+	return t.files
+}
+
+// SetReconnect 设置断开后在当前终端上重新连接的方法
+func (t *Term) SetReconnect(fn func() error) {
+	t.reconnect = fn
+}
+
+// Reconnect 在断开的终端上重新连接，不支持重连或未断开时返回 false
+func (t *Term) Reconnect(callback func(err error)) bool {
+	if t.reconnect == nil || t.Stat() != TermStatDisconnected {
+		return false
+	}
+	go func() {
+		callback(t.reconnect())
+	}()
+	return true
+}
+
 func (t *Term) StartWithPipe(callback func(err error)) (io.WriteCloser, io.Reader) {
 
 	pipe1Reader, pipe1Writer := io.Pipe()
@@ -63,8 +212,17 @@ func (t *Term) RunWithReadWriteCloser(wr io.ReadWriteCloser) error {
 	return t.term.RunWithConnection(wr, wr)
 }
 
+// SetDetach 设置关闭标签页时断开连接的方法，设置后不再向远端发送 EOF
+func (t *Term) SetDetach(fn func()) {
+	t.detach = fn
+}
+
 func (t *Term) Exit() {
-	t.term.Exit()
+	if t.detach != nil {
+		t.detach()
+	} else {
+		t.term.Exit()
+	}
 	for _, listener := range t.closeListeners {
 		listener()
 	}
@@ -80,9 +238,12 @@ func (t *Term) FocusGained() {
 	}
 }
 
+// AddConfigListener 监听终端大小和标题变化，可以在任意线程调用
 func (t *Term) AddConfigListener(fn func(config *terminal.Config)) {
 	if fn != nil {
+		t.configLock.Lock()
 		t.configListeners = append(t.configListeners, fn)
+		t.configLock.Unlock()
 	}
 }
 
@@ -99,9 +260,14 @@ func (t *Term) watchConfig() {
 		for {
 			select {
 			case cfg := <-cfgChan:
+				t.configLock.Lock()
 				t.termConfig = &cfg
-				for _, listener := range t.configListeners {
-					listener(t.termConfig)
+				listeners := t.configListeners
+				t.configLock.Unlock()
+				for _, listener := range listeners {
+					// 每个监听者得到独立的副本，避免与下一次更新共享
+					copied := cfg
+					listener(&copied)
 				}
 			}
 		}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"sync"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/test"
+	"github.com/fyne-io/terminal"
+)
+
+// TestTermFit 测试终端按区域大小计算行列数
+func TestTermFit(t *testing.T) {
+	test.NewTempApp(t)
+	term := NewTerm("test", nil)
+
+	rows, cols := term.Fit(fyne.Size{})
+	if rows != 24 || cols != 80 {
+		t.Errorf("Expected default size 24x80, got %dx%d", rows, cols)
+	}
+
+	smallRows, smallCols := term.Fit(fyne.NewSize(200, 100))
+	bigRows, bigCols := term.Fit(fyne.NewSize(800, 400))
+	if smallRows == 0 || smallCols == 0 {
+		t.Fatalf("Expected non-zero size, got %dx%d", smallRows, smallCols)
+	}
+	if bigRows <= smallRows || bigCols <= smallCols {
+		t.Errorf("Expected larger area to fit more cells, got %dx%d and %dx%d", smallRows, smallCols, bigRows, bigCols)
+	}
+}
+
+// TestTermDetach 测试设置 detach 后关闭终端不再向远端发送 EOF
+func TestTermDetach(t *testing.T) {
+	test.NewTempApp(t)
+	term := NewTerm("test", nil)
+	var detached, closed bool
+	term.SetDetach(func() {
+		detached = true
+	})
+	term.AddCloseListener(func() {
+		closed = true
+	})
+	term.Exit()
+	if !detached || !closed {
+		t.Errorf("Exit() detached=%v closed=%v", detached, closed)
+	}
+}
+
+// TestTermConfigConcurrent 测试后台添加监听者、读取大小与终端调整大小同时进行，配合 -race 运行
+func TestTermConfigConcurrent(t *testing.T) {
+	test.NewTempApp(t)
+	term := NewTerm("test", nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				term.AddConfigListener(func(config *terminal.Config) {})
+				term.Size()
+				term.TermConfig()
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		term.Fit(fyne.NewSize(float32(200+i*10), float32(100+i*10)))
+	}
+	wg.Wait()
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// activeForward 转发面板中的一条端口转发，SSH 转发和 K8S 转发都实现该接口
+type activeForward interface {
+	Title() string  // 会话和规则
+	Status() string // 流量统计或运行状态
+	Close() error
+}
+
+// addTunnels 登记正在运行的端口转发
+func (w *Window) addTunnels(tunnels ...activeForward) {
+	w.tunnelLock.Lock()
+	defer w.tunnelLock.Unlock()
+	w.tunnels = append(w.tunnels, tunnels...)
+}
+
+// removeTunnels 关闭并移除端口转发
+func (w *Window) removeTunnels(tunnels ...activeForward) {
+	w.tunnelLock.Lock()
+	defer w.tunnelLock.Unlock()
+	for _, tunnel := range tunnels {
+		tunnel.Close()
+		for i, t := range w.tunnels {
+			if t == tunnel {
+				w.tunnels = append(w.tunnels[:i], w.tunnels[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// activeTunnels 返回当前端口转发的快照
+func (w *Window) activeTunnels() []activeForward {
+	w.tunnelLock.Lock()
+	defer w.tunnelLock.Unlock()
+	return append([]activeForward{}, w.tunnels...)
+}
+
+// formatBytes 将字节数格式化为易读的形式
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// showTunnelsDialog 显示端口转发列表，每秒刷新流量统计
+func (w *Window) showTunnelsDialog() {
+	tunnels := w.activeTunnels()
+	var refresh func()
+	list := widget.NewList(func() int {
+		return len(tunnels)
+	}, func() fyne.CanvasObject {
+		return container.NewHBox(
+			widget.NewLabel(""), // 会话和规则
+			layout.NewSpacer(),
+			widget.NewLabel(""), // 流量统计
+			widget.NewButtonWithIcon("", theme.MediaStopIcon(), nil),
+		)
+	}, func(id widget.ListItemID, object fyne.CanvasObject) {
+		box := object.(*fyne.Container)
+		label := box.Objects[0].(*widget.Label)
+		stats := box.Objects[2].(*widget.Label)
+		stop := box.Objects[3].(*widget.Button)
+
+		tunnel := tunnels[id]
+		label.SetText(tunnel.Title())
+		stats.SetText(tunnel.Status())
+		stop.OnTapped = func() {
+			w.removeTunnels(tunnel)
+			refresh()
+		}
+	})
+
+	emptyLabel := widget.NewLabel("No active tunnels.")
+	emptyLabel.Alignment = fyne.TextAlignCenter
+	content := container.NewStack(list, container.NewCenter(emptyLabel))
+	refresh = func() {
+		tunnels = w.activeTunnels()
+		emptyLabel.Hidden = len(tunnels) > 0
+		list.Refresh()
+		emptyLabel.Refresh()
+	}
+	refresh()
+
+	done := make(chan struct{})
+	dlg := dialog.NewCustom("Tunnels", "Close", content, w.win)
+	dlg.SetOnClosed(func() {
+		close(done)
+	})
+	go func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				fyne.Do(refresh)
+			}
+		}
+	}()
+	dlg.Resize(fyne.NewSize(600, 400))
+	dlg.Show()
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"fmt"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
 	"fyne.io/fyne/v2/container"
@@ -9,6 +10,10 @@ import (
 	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 	"github.com/fyne-io/terminal"
+	"io"
+	"log"
+	"strings"
+	"sync"
 )
 
 const APP_NAME = "Go Shell"
@@ -92,22 +97,110 @@ type Window struct {
 	cmds  []*Cmd
 
 	cmdbar *fyne.Container
+
+	tunnels    []activeForward
+	tunnelLock sync.Mutex
 }
 
 func (w *Window) AddTermTab(tab *Term) {
-	tabItem := container.TabItem{Text: tab.Name(), Icon: theme.ComputerIcon(), Content: tab.term}
+	var content fyne.CanvasObject = tab.term
+	if tab.side != nil {
+		split := container.NewHSplit(tab.term, tab.side)
+		split.Offset = 0.65
+		content = split
+	}
+	tabItem := container.TabItem{Text: tab.Name(), Icon: theme.ComputerIcon(), Content: content}
+	title := tab.Name()
 	tab.AddConfigListener(func(config *terminal.Config) {
 		if len(config.Title) > 0 {
-			tabItem.Text = config.Title
+			title = config.Title
 		} else {
-			tabItem.Text = tab.Name()
+			title = tab.Name()
 		}
+		tabItem.Text = tabTitle(title, tab.Stat())
+	})
+	// 连接断开时在标签页上显示状态
+	tab.AddStatListener(func(stat string) {
+		fyne.Do(func() {
+			tabItem.Text = tabTitle(title, stat)
+			if stat == TermStatDisconnected {
+				tabItem.Icon = theme.ErrorIcon()
+			} else {
+				tabItem.Icon = theme.ComputerIcon()
+			}
+			w.tabs.Refresh()
+		})
 	})
 	w.tabs.Append(&tabItem)
 	w.terms[&tabItem] = tab
 	w.tabs.Select(&tabItem)
 }
 
+// tabTitle 返回带连接状态的标签页标题
+func tabTitle(title, stat string) string {
+	switch stat {
+	case TermStatDisconnected, TermStatConnecting:
+		return fmt.Sprintf("%s (%s)", title, stat)
+	}
+	return title
+}
+
+// reconnectTerm 重新连接当前标签页中已断开的会话
+func (w *Window) reconnectTerm() {
+	item := w.tabs.Selected()
+	if item == nil {
+		return
+	}
+	if term, ok := w.terms[item]; ok {
+		term.Reconnect(func(err error) {
+			if err != nil {
+				log.Println(err)
+				w.showError(err)
+			}
+		})
+	}
+}
+
+// showConnectionInfoDialog 显示当前标签页的连接信息
+func (w *Window) showConnectionInfoDialog() {
+	item := w.tabs.Selected()
+	if item == nil {
+		return
+	}
+	term, ok := w.terms[item]
+	if !ok || len(term.Info()) == 0 {
+		dialog.ShowInformation("Connection Info", "No connection info available.", w.win)
+		return
+	}
+	form := widget.NewForm()
+	for _, info := range term.Info() {
+		value := widget.NewLabel(info.Value)
+		value.Wrapping = fyne.TextWrapWord
+		form.Append(info.Name, value)
+	}
+	dlg := dialog.NewCustom("Connection Info: "+term.Name(), "Close", form, w.win)
+	dlg.Resize(fyne.NewSize(500, 0))
+	dlg.Show()
+}
+
+// termSize 返回终端标签页内容区域的大小
+func (w *Window) termSize() fyne.Size {
+	if item := w.tabs.Selected(); item != nil && item.Content != nil {
+		return item.Content.Size()
+	}
+	return w.tabs.Size()
+}
+
+// toggleSidePanel 显示或隐藏当前标签页的侧边面板
+func (w *Window) toggleSidePanel() {
+	if item := w.tabs.Selected(); item != nil {
+		if term, ok := w.terms[item]; ok {
+			term.ToggleSidePanel()
+			item.Content.Refresh()
+		}
+	}
+}
+
 func (w *Window) AddConfig(conf *SSHConfig) {
 	w.confs = append(w.confs, conf)
 	w.save()
@@ -167,6 +260,10 @@ func (w *Window) RemoveConfig(index int) {
 	if index < 0 || index > len(w.confs) {
 		return
 	}
+	// 释放配置持有的资源，例如 K8S 端口转发
+	if closer, ok := w.confs[index].(io.Closer); ok {
+		closer.Close()
+	}
 	w.confs = append(w.confs[:index], w.confs[index+1:]...)
 	w.save()
 }
@@ -191,6 +288,9 @@ func (w *Window) Run(stop <-chan struct{}) {
 	w.initUI()
 
 	w.win.ShowAndRun()
+
+	// 退出时停止所有端口转发
+	w.removeTunnels(w.activeTunnels()...)
 }
 
 func (w *Window) initUI() {
@@ -201,6 +301,18 @@ func (w *Window) initUI() {
 		w.showCreateConfigDialog()
 	}), widget.NewToolbarAction(theme.ListIcon(), func() {
 		w.showCmdManagerDialog()
+	}), widget.NewToolbarAction(theme.MailForwardIcon(), func() {
+		w.showTunnelsDialog()
+	}), widget.NewToolbarAction(theme.FolderIcon(), func() {
+		w.toggleSidePanel()
+	}), widget.NewToolbarAction(theme.UploadIcon(), func() {
+		w.showUploadDialog()
+	}), widget.NewToolbarAction(theme.DownloadIcon(), func() {
+		w.showDownloadDialog()
+	}), widget.NewToolbarAction(theme.ViewRefreshIcon(), func() {
+		w.reconnectTerm()
+	}), widget.NewToolbarAction(theme.VisibilityIcon(), func() {
+		w.showConnectionInfoDialog()
 	}),
 		widget.NewToolbarSpacer(),
 		widget.NewToolbarAction(theme.SettingsIcon(), func() {
@@ -264,6 +376,10 @@ func (w *Window) initUI() {
 	content := container.NewBorder(toolbar, w.cmdbar, nil, nil, center)
 
 	w.win.SetContent(content)
+	// 拖放本地文件到容器会话时确认目标路径后上传
+	w.win.SetOnDropped(func(_ fyne.Position, uris []fyne.URI) {
+		w.uploadDropped(uris)
+	})
 }
 
 func (w *Window) showAboutDialog() {
@@ -271,7 +387,69 @@ func (w *Window) showAboutDialog() {
 }
 
 func (w *Window) showError(e error) {
-	dialog.ShowError(e, w.win)
+	fyne.Do(func() {
+		dialog.ShowError(e, w.win)
+	})
+}
+
+// promptSecret 弹出密码输入框并等待用户输入，不能在主线程中调用
+func (w *Window) promptSecret(title, label string) (string, bool) {
+	result := make(chan string, 1)
+	fyne.Do(func() {
+		entry := widget.NewPasswordEntry()
+		dlg := dialog.NewForm(title, "OK", "Cancel", []*widget.FormItem{
+			widget.NewFormItem(label, entry),
+		}, func(b bool) {
+			if b {
+				result <- entry.Text
+			} else {
+				close(result)
+			}
+		}, w.win)
+		dlg.Resize(fyne.Size{Width: 300})
+		dlg.Show()
+	})
+	secret, ok := <-result
+	return secret, ok
+}
+
+// promptChallenge 显示交互式认证的问题并等待用户回答，不能在主线程中调用
+func (w *Window) promptChallenge(name, instruction string, questions []string, echos []bool) ([]string, bool) {
+	result := make(chan []string, 1)
+	fyne.Do(func() {
+		entries := make([]*widget.Entry, len(questions))
+		items := make([]*widget.FormItem, 0, len(questions)+1)
+		if instruction != "" {
+			items = append(items, widget.NewFormItem("", widget.NewLabel(instruction)))
+		}
+		for i, question := range questions {
+			if echos[i] {
+				entries[i] = widget.NewEntry()
+			} else {
+				entries[i] = widget.NewPasswordEntry()
+			}
+			items = append(items, widget.NewFormItem(strings.TrimSpace(question), entries[i]))
+		}
+		title := name
+		if title == "" {
+			title = "Authentication"
+		}
+		dlg := dialog.NewForm(title, "OK", "Cancel", items, func(b bool) {
+			if b {
+				answers := make([]string, len(entries))
+				for i, entry := range entries {
+					answers[i] = entry.Text
+				}
+				result <- answers
+			} else {
+				close(result)
+			}
+		}, w.win)
+		dlg.Resize(fyne.Size{Width: 300})
+		dlg.Show()
+	})
+	answers, ok := <-result
+	return answers, ok
 }
 
 func (w *Window) sendCmd(cmd *Cmd) {